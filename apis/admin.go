@@ -0,0 +1,191 @@
+package apis
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/event"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+// bindAdminApi registers the admin endpoints, requiring the admin scope
+// even on the admin listeners.
+func bindAdminApi(r *router) {
+	r.get("/api/v1/admin/log-level", RequireScope(core.ScopeAdmin, getLogLevel))
+	r.put("/api/v1/admin/log-level", RequireScope(core.ScopeAdmin, setLogLevel))
+	r.get("/api/v1/admin/logs", RequireScope(core.ScopeAdmin, listLogs))
+}
+
+const (
+	defaultLogsLimit = 100
+	maxLogsLimit     = 1000
+)
+
+// logLevelState is the level of the app logger or of a component.
+type logLevelState struct {
+	Level     logging.LogLevel `json:"level"`
+	ExpiresAt *time.Time       `json:"expires_at,omitempty"`
+
+	// Inherited is set for the components using the app logger level.
+	Inherited bool `json:"inherited,omitempty"`
+}
+
+type logLevelResponse struct {
+	logLevelState
+	Components map[string]logLevelState `json:"components"`
+}
+
+type logLevelRequest struct {
+	Level     logging.LogLevel `json:"level"`
+	Component string           `json:"component"`
+
+	// TTL is a Go duration after which the change is reverted, e.g. `15m`.
+	TTL string `json:"ttl"`
+}
+
+func getLogLevel(e *core.EventRequest) error {
+	return e.Json(newLogLevelResponse(e.App.LogLevels()), http.StatusOK)
+}
+
+func setLogLevel(e *core.EventRequest) error {
+	var data logLevelRequest
+
+	if err := e.BindJson(&data); err != nil {
+		return err
+	}
+
+	details := make(map[string]event.FieldError)
+
+	if data.Level == "" {
+		details["level"] = event.NewFieldError("required", "cannot be blank")
+	} else if !data.Level.IsValid() {
+		details["level"] = event.NewFieldError("invalid", "must be one of debug, info, warn or error")
+	}
+
+	if data.Component != "" && !slices.Contains(core.LogComponents, data.Component) {
+		details["component"] = event.NewFieldError(
+			"invalid",
+			"must be one of "+strings.Join(core.LogComponents, ", "),
+		)
+	}
+
+	var ttl time.Duration
+
+	if data.TTL != "" {
+		d, err := time.ParseDuration(data.TTL)
+		if err != nil || d <= 0 {
+			details["ttl"] = event.NewFieldError("invalid", "must be a positive duration, e.g. 15m")
+		}
+		ttl = d
+	}
+
+	if len(details) > 0 {
+		return e.ValidationError("", details)
+	}
+
+	e.App.LogLevels().Set(data.Component, data.Level, ttl)
+
+	e.Logger().Info("log level changed",
+		slog.String("level", string(data.Level)),
+		slog.String("log_component", data.Component),
+		slog.Duration("ttl", ttl),
+	)
+
+	return e.Json(newLogLevelResponse(e.App.LogLevels()), http.StatusOK)
+}
+
+func newLogLevelResponse(levels *logging.Levels) logLevelResponse {
+	resp := logLevelResponse{
+		logLevelState: newLogLevelState(levels.Level("")),
+		Components:    make(map[string]logLevelState, len(core.LogComponents)),
+	}
+
+	overrides := levels.Components()
+
+	for _, component := range core.LogComponents {
+		state, ok := overrides[component]
+		if !ok {
+			resp.Components[component] = logLevelState{Level: resp.Level, Inherited: true}
+			continue
+		}
+		resp.Components[component] = newLogLevelState(state)
+	}
+
+	return resp
+}
+
+func newLogLevelState(state logging.LevelState) logLevelState {
+	s := logLevelState{Level: state.Level}
+
+	if !state.ExpiresAt.IsZero() {
+		s.ExpiresAt = &state.ExpiresAt
+	}
+
+	return s
+}
+
+// listLogs returns the persisted logs, the most recent first. The `since`
+// filter is either a RFC 3339 time or a Go duration before now, e.g. `1h`.
+func listLogs(e *core.EventRequest) error {
+	store := e.App.LogStore()
+	if store == nil {
+		return e.NotFoundError("Logs persistence is disabled.")
+	}
+
+	params := e.Request.URL.Query()
+	details := make(map[string]event.FieldError)
+
+	query := core.LogsQuery{
+		Level:     logging.LogLevel(params.Get("level")),
+		RequestID: params.Get("request_id"),
+		Limit:     defaultLogsLimit,
+	}
+
+	if query.Level != "" && !query.Level.IsValid() {
+		details["level"] = event.NewFieldError("invalid", "must be one of debug, info, warn or error")
+	}
+
+	if since := params.Get("since"); since != "" {
+		if t, err := time.Parse(time.RFC3339, since); err == nil {
+			query.Since = t
+		} else if d, err := time.ParseDuration(since); err == nil && d > 0 {
+			query.Since = time.Now().Add(-d)
+		} else {
+			details["since"] = event.NewFieldError("invalid", "must be a RFC 3339 time or a positive duration, e.g. 1h")
+		}
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxLogsLimit {
+			details["limit"] = event.NewFieldError("invalid", "must be a number between 1 and "+strconv.Itoa(maxLogsLimit))
+		}
+		query.Limit = n
+	}
+
+	if len(details) > 0 {
+		return e.ValidationError("", details)
+	}
+
+	logs, err := store.FindLogs(e.Request.Context(), query)
+	if err != nil {
+		return err
+	}
+
+	if logs == nil {
+		logs = []*core.Log{}
+	}
+
+	resp := struct {
+		Logs []*core.Log `json:"logs"`
+	}{
+		Logs: logs,
+	}
+
+	return e.Json(resp, http.StatusOK)
+}
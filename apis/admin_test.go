@@ -0,0 +1,232 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tests"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+func TestGetLogLevel(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "anonymous request",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": ""},
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+			},
+		},
+		{
+			name:           "missing admin scope",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_reader"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 403,
+			expectedContent: []string{
+				`"code":"FORBIDDEN"`,
+			},
+		},
+		{
+			name:           "default levels",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"level":"info","components":{`,
+				`"http":{"level":"info","inherited":true}`,
+				`"sync":{"level":"info","inherited":true}`,
+				`"store":{"level":"info","inherited":true}`,
+			},
+		},
+		{
+			name:   "component level",
+			url:    "/api/v1/admin/log-level",
+			admin:  true,
+			method: http.MethodGet,
+			beforeTest: func(t *testing.T, app *tests.TestApp) {
+				app.LogLevels().Set("", logging.LevelWarn, 0)
+				app.LogLevels().Set("sync", logging.LevelDebug, time.Hour)
+			},
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"level":"warn","components":{`,
+				`"http":{"level":"warn","inherited":true}`,
+				`"sync":{"level":"debug","expires_at":"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestSetLogLevel(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "root level",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodPut,
+			body:           strings.NewReader(`{"level":"debug"}`),
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"level":"debug","components":{`,
+				`"sync":{"level":"debug","inherited":true}`,
+			},
+		},
+		{
+			name:           "component level with ttl",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodPut,
+			body:           strings.NewReader(`{"level":"error","component":"store","ttl":"15m"}`),
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"level":"info","components":{`,
+				`"store":{"level":"error","expires_at":"`,
+				`"http":{"level":"info","inherited":true}`,
+			},
+		},
+		{
+			name:           "invalid values",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodPut,
+			body:           strings.NewReader(`{"level":"verbose","component":"other","ttl":"-1m"}`),
+			expectedStatus: 422,
+			expectedContent: []string{
+				`"code":"VALIDATION_FAILED"`,
+				`"level":{"code":"invalid"`,
+				`"component":{"code":"invalid","message":"Must be one of http, sync, store."}`,
+				`"ttl":{"code":"invalid"`,
+			},
+		},
+		{
+			name:           "missing level",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodPut,
+			body:           strings.NewReader(`{"component":"sync"}`),
+			expectedStatus: 422,
+			expectedContent: []string{
+				`"level":{"code":"required"`,
+			},
+		},
+		{
+			name:           "invalid body",
+			url:            "/api/v1/admin/log-level",
+			admin:          true,
+			method:         http.MethodPut,
+			body:           strings.NewReader(`{"level":`),
+			expectedStatus: 400,
+			expectedContent: []string{
+				`"code":"BAD_REQUEST"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestListLogs(t *testing.T) {
+	t.Parallel()
+
+	withLogs := func(t *testing.T, app *tests.TestApp) {
+		store := core.NewMemoryLogStore(0)
+
+		err := store.SaveLogs(context.Background(), []*core.Log{
+			{Created: time.Now().Add(-2 * time.Hour), Level: logging.LevelError, Message: "old error"},
+			{Created: time.Now(), Level: logging.LevelInfo, Message: "request", Data: map[string]any{"request_id": "abc"}},
+			{Created: time.Now(), Level: logging.LevelError, Message: "new error", Data: map[string]any{"request_id": "abc"}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app.Config().LogsPersist = true
+		app.Config().LogsStore = store
+	}
+
+	scenarios := []apiTestScenario{
+		{
+			name:            "persistence disabled",
+			url:             "/api/v1/admin/logs",
+			admin:           true,
+			method:          http.MethodGet,
+			expectedStatus:  404,
+			expectedContent: []string{`"message":"Logs persistence is disabled."`},
+		},
+		{
+			name:           "all logs",
+			url:            "/api/v1/admin/logs",
+			admin:          true,
+			method:         http.MethodGet,
+			beforeTest:     withLogs,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"message":"new error"`,
+				`"message":"request"`,
+				`"message":"old error"`,
+			},
+		},
+		{
+			name:           "filtered logs",
+			url:            "/api/v1/admin/logs?level=error&since=1h&request_id=abc",
+			admin:          true,
+			method:         http.MethodGet,
+			beforeTest:     withLogs,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"logs":[{"id":"`,
+				`"level":"error","message":"new error","data":{"request_id":"abc"}}]}`,
+			},
+		},
+		{
+			name:           "no match",
+			url:            "/api/v1/admin/logs?request_id=other",
+			admin:          true,
+			method:         http.MethodGet,
+			beforeTest:     withLogs,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"logs":[]}`,
+			},
+		},
+		{
+			name:           "invalid filters",
+			url:            "/api/v1/admin/logs?level=verbose&since=yesterday&limit=0",
+			admin:          true,
+			method:         http.MethodGet,
+			beforeTest:     withLogs,
+			expectedStatus: 422,
+			expectedContent: []string{
+				`"level":{"code":"invalid"`,
+				`"since":{"code":"invalid"`,
+				`"limit":{"code":"invalid"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
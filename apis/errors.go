@@ -1,25 +1,70 @@
 package apis
 
 import (
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
 
 	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/event"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// handleError translates an error returned by a route handler into an api response.
+func handleError(e *core.EventRequest, err error) {
+	var apiErr *event.ApiError
+
+	switch {
+	case errors.As(err, &apiErr):
+		if apiErr.Status >= http.StatusInternalServerError {
+			logError(e, err, apiErr.Status, apiErr.Code)
+		}
+	case errors.Is(err, core.ErrNotFound):
+		apiErr = e.NotFoundError("")
+	case errors.Is(err, core.ErrConflict):
+		apiErr = e.ConflictError("")
+	default:
+		internalServerError(e, err)
+		return
+	}
+
+	// Copy the error to avoid mutating shared ApiError values.
+	resp := *apiErr
+	resp.RequestID = e.RequestID()
+
+	writeError(e, &resp)
+}
+
 func internalServerError(e *core.EventRequest, err error) {
-	e.App.Logger().Error("internal server error",
-		slog.String("code", "INTERNAL_SERVER_ERROR"),
-		slog.String("error", fmt.Sprintf("%v", err)),
-		slog.String("method", e.Request.Method),
-		slog.String("request", e.Request.RequestURI),
-	)
+	logError(e, err, http.StatusInternalServerError, event.CodeInternalServerError)
+	writeError(e, e.InternalServerError("").WithRequestID(e.RequestID()))
+}
 
-	resp := e.InternalServerError("")
+// writeError writes the error response, unless the handler already
+// wrote a response before failing.
+func writeError(e *core.EventRequest, apiErr *event.ApiError) {
+	if rw, ok := e.Response.(*event.ResponseWriter); ok && rw.Written() {
+		e.Logger().Warn("error not sent, the response was already written",
+			slog.Int("status", apiErr.Status),
+			slog.String("code", apiErr.Code),
+		)
+		return
+	}
 
-	if err := e.Json(resp, resp.Status); err != nil {
-		_ = e.Status(http.StatusInternalServerError)
+	if err := e.Json(apiErr, apiErr.Status); err != nil {
+		_ = e.Status(apiErr.Status)
 		return
 	}
 }
+
+func logError(e *core.EventRequest, err error, status int, code string) {
+	trace.SpanFromContext(e.Request.Context()).RecordError(err)
+
+	e.Logger().Error("request failed",
+		slog.Int("status", status),
+		slog.String("code", code),
+		slog.String("error", fmt.Sprintf("%v", err)),
+		slog.String("request", e.Request.RequestURI),
+	)
+}
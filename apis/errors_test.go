@@ -1,8 +1,10 @@
 package apis
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dlbarduzzi/sentinel/core"
@@ -31,3 +33,69 @@ func TestInternalServerError(t *testing.T) {
 	// For example, removing `Event` from `core.EventRequest` would cause this test to fail.
 	internalServerError(e, nil)
 }
+
+func TestHandleError(t *testing.T) {
+	testCases := []struct {
+		name            string
+		handler         func(e *core.EventRequest) error
+		expectedStatus  int
+		expectedBody    string
+		expectedContent []string
+	}{
+		{
+			name: "service unavailable",
+			handler: func(e *core.EventRequest) error {
+				return event.NewApiError(http.StatusServiceUnavailable, "")
+			},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `"code":"SERVICE_UNAVAILABLE"`,
+			expectedContent: []string{
+				`"msg":"request failed"`,
+				`"status":503`,
+			},
+		},
+		{
+			name: "response already written",
+			handler: func(e *core.EventRequest) error {
+				if err := e.Json(map[string]string{"partial": "ok"}, http.StatusOK); err != nil {
+					return err
+				}
+				return errors.New("failed after writing")
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"partial":"ok"}`,
+			expectedContent: []string{
+				`"msg":"request failed"`,
+				`"msg":"error not sent, the response was already written"`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app, buf := newBufferedApp(t)
+
+			router := &router{app: app}
+			router.get("/test", tc.handler)
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+			router.buildMux().ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status code to be %d, got %d", tc.expectedStatus, rec.Code)
+			}
+
+			if body := rec.Body.String(); !strings.Contains(body, tc.expectedBody) || strings.Count(body, "\n") != 1 {
+				t.Fatalf("expected a single body containing %s, got \n%v", tc.expectedBody, body)
+			}
+
+			for _, content := range tc.expectedContent {
+				if !strings.Contains(buf.String(), content) {
+					t.Errorf("expected content %v in logs \n%v", content, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -4,13 +4,23 @@ import (
 	"net/http"
 
 	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/health"
 )
 
+// healthPaths lists the health endpoints polled by load balancers and probes.
+var healthPaths = []string{
+	"/api/v1/health",
+	"/api/v1/health/live",
+	"/api/v1/health/ready",
+}
+
 func bindHealthApi(r *router) {
 	r.get("/api/v1/health", healthCheck)
+	r.get("/api/v1/health/live", healthLive)
+	r.get("/api/v1/health/ready", healthReady)
 }
 
-func healthCheck(e *core.EventRequest) {
+func healthCheck(e *core.EventRequest) error {
 	resp := struct {
 		Status  int    `json:"status"`
 		Message string `json:"message"`
@@ -19,8 +29,34 @@ func healthCheck(e *core.EventRequest) {
 		Message: "API is healthy.",
 	}
 
-	if err := e.Json(resp, resp.Status); err != nil {
-		internalServerError(e, err)
-		return
+	return e.Json(resp, resp.Status)
+}
+
+func healthLive(e *core.EventRequest) error {
+	report := e.App.HealthChecks().Live(e.Request.Context())
+	return healthReport(e, report, "API is alive.", "API is not alive.")
+}
+
+func healthReady(e *core.EventRequest) error {
+	report := e.App.HealthChecks().Ready(e.Request.Context())
+	return healthReport(e, report, "API is ready.", "API is not ready.")
+}
+
+func healthReport(e *core.EventRequest, report *health.Report, up, down string) error {
+	resp := struct {
+		Status  int             `json:"status"`
+		Message string          `json:"message"`
+		Checks  []health.Result `json:"checks"`
+	}{
+		Status:  http.StatusOK,
+		Message: up,
+		Checks:  report.Checks,
+	}
+
+	if !report.Healthy() {
+		resp.Status = http.StatusServiceUnavailable
+		resp.Message = down
 	}
+
+	return e.Json(resp, resp.Status)
 }
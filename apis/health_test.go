@@ -1,8 +1,13 @@
 package apis
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"testing"
+
+	"github.com/dlbarduzzi/sentinel/tests"
+	"github.com/dlbarduzzi/sentinel/tools/health"
 )
 
 func TestHealthCheck(t *testing.T) {
@@ -21,3 +26,107 @@ func TestHealthCheck(t *testing.T) {
 
 	s.Test(t)
 }
+
+func TestHealthLive(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "no checks",
+			url:            "/api/v1/health/live",
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"status":200`,
+				`"message":"API is alive."`,
+				`"checks":[]`,
+			},
+		},
+		{
+			name:           "readiness checks are skipped",
+			url:            "/api/v1/health/live",
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"status":200`,
+				`"checks":[{"name":"loop","status":"up"`,
+			},
+			beforeTest: func(t *testing.T, app *tests.TestApp) {
+				registerCheck(t, app, "loop", health.KindLiveness, nil)
+				registerCheck(t, app, "store", health.KindReadiness, errors.New("unreachable"))
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestHealthReady(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "passing checks",
+			url:            "/api/v1/health/ready",
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"status":200`,
+				`"message":"API is ready."`,
+				`{"name":"store","status":"up"`,
+			},
+			beforeTest: func(t *testing.T, app *tests.TestApp) {
+				registerCheck(t, app, "store", health.KindReadiness, nil)
+			},
+		},
+		{
+			name:           "failing check",
+			url:            "/api/v1/health/ready",
+			method:         http.MethodGet,
+			expectedStatus: 503,
+			expectedContent: []string{
+				`"status":503`,
+				`"message":"API is not ready."`,
+				`{"name":"loop","status":"up"`,
+				`"error":"unreachable"`,
+			},
+			beforeTest: func(t *testing.T, app *tests.TestApp) {
+				registerCheck(t, app, "loop", health.KindLiveness, nil)
+				registerCheck(t, app, "store", health.KindReadiness, errors.New("unreachable"))
+			},
+		},
+		{
+			name:           "shutting down",
+			url:            "/api/v1/health/ready",
+			method:         http.MethodGet,
+			expectedStatus: 503,
+			expectedContent: []string{
+				`"status":503`,
+				`{"name":"shutdown","status":"down"`,
+			},
+			beforeTest: func(_ *testing.T, app *tests.TestApp) {
+				app.HealthChecks().SetShuttingDown(true)
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func registerCheck(t *testing.T, app *tests.TestApp, name string, kind health.Kind, err error) {
+	t.Helper()
+
+	check := health.Check{
+		Name: name,
+		Kind: kind,
+		Func: func(context.Context) error { return err },
+	}
+
+	if err := app.HealthChecks().Register(check); err != nil {
+		t.Fatal(err)
+	}
+}
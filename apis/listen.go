@@ -0,0 +1,133 @@
+package apis
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// unixPrefix marks the listener addresses that are Unix socket paths.
+const unixPrefix = "unix:"
+
+// ListenerConfig defines an additional listener of the api server.
+type ListenerConfig struct {
+	// Name identifies the listener in the logs, `public` or `admin` by
+	// default.
+	Name string
+
+	// Addr is a tcp address, e.g. `127.0.0.1:9090`, or a Unix socket path
+	// prefixed with `unix:`, e.g. `unix:/run/sentinel/admin.sock`.
+	Addr string
+
+	// Listener accepts the connections instead of listening on Addr.
+	Listener net.Listener
+
+	// Admin serves the admin endpoints and the metrics instead of the
+	// public routes. The admin endpoints are only served by the admin
+	// listeners.
+	Admin bool
+
+	// TLS serves the listener over https when enabled.
+	TLS TLSConfig
+
+	// Middlewares wrap the routes of this listener only, inside the
+	// middlewares of its router.
+	Middlewares []func(http.Handler) http.Handler
+}
+
+// listen announces on a tcp address or, with the `unix:` prefix, on a
+// Unix socket. A stale socket file left by a previous process is removed,
+// unless another process still accepts connections on it.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode().Type() == fs.ModeSocket:
+		if conn, err := net.Dial("unix", path); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("socket %q is in use", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	case err != nil && !errors.Is(err, fs.ErrNotExist):
+		return nil, err
+	}
+
+	listener, err := listenUnix(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The socket is created without group permissions, then local agents
+	// running as another user of the group can connect.
+	if err := os.Chmod(path, 0o660); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// limitListener waits to accept new connections while the connections
+// counted by sem, possibly shared with other listeners, reach its capacity.
+type limitListener struct {
+	net.Listener
+
+	sem       chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLimitListener(listener net.Listener, sem chan struct{}) *limitListener {
+	return &limitListener{
+		Listener: listener,
+		sem:      sem,
+		done:     make(chan struct{}),
+	}
+}
+
+func (l *limitListener) Accept() (net.Conn, error) {
+	select {
+	case l.sem <- struct{}{}:
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		<-l.sem
+		return nil, err
+	}
+
+	return &limitConn{Conn: conn, sem: l.sem}, nil
+}
+
+func (l *limitListener) Close() error {
+	err := l.Listener.Close()
+	l.closeOnce.Do(func() { close(l.done) })
+	return err
+}
+
+// limitConn releases its slot of the connections limit once closed.
+type limitConn struct {
+	net.Conn
+
+	sem       chan struct{}
+	closeOnce sync.Once
+}
+
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(func() { <-c.sem })
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package apis
+
+import "net"
+
+// listenUnix creates the socket, the platform has no umask to restrict its
+// mode before the socket gets its final mode.
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
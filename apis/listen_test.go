@@ -0,0 +1,109 @@
+package apis
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinel.sock")
+
+	listener, err := listen("unix:" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o660 {
+		t.Fatalf("expected socket permissions to be %v, got %v", os.FileMode(0o660), perm)
+	}
+
+	if _, err := listen("unix:" + path); err == nil {
+		t.Fatal("expected error for a socket in use")
+	}
+
+	// Leave a stale socket file behind, as a killed process would.
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = listener.Close()
+
+	listener, err = listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got %v", err)
+	}
+	_ = listener.Close()
+
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := listen("unix:" + file); err == nil {
+		t.Fatal("expected error for an existing regular file")
+	}
+}
+
+func TestLimitListener(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	limited := newLimitListener(listener, make(chan struct{}, 1))
+	defer limited.Close()
+
+	accepted := make(chan net.Conn, 2)
+
+	go func() {
+		for {
+			conn, err := limited.Accept()
+			if err != nil {
+				close(accepted)
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	for range 2 {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+
+	first := <-accepted
+
+	select {
+	case <-accepted:
+		t.Fatal("expected the second connection to wait for the limit")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	_ = first.Close()
+
+	select {
+	case <-accepted:
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the second connection to be accepted once the first is closed")
+	}
+
+	// Closing the listener releases the Accept call waiting for a slot.
+	_ = limited.Close()
+
+	select {
+	case _, ok := <-accepted:
+		if ok {
+			t.Fatal("expected no more accepted connections")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected Accept to return once the listener is closed")
+	}
+}
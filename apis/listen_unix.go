@@ -0,0 +1,25 @@
+//go:build unix
+
+package apis
+
+import (
+	"net"
+	"sync"
+	"syscall"
+)
+
+// umaskMu serializes the umask changes of listenUnix.
+var umaskMu sync.Mutex
+
+// listenUnix creates the socket under a umask giving no permission to the
+// group and the other users, so that no connection is accepted before the
+// socket gets its final mode.
+func listenUnix(path string) (net.Listener, error) {
+	umaskMu.Lock()
+	defer umaskMu.Unlock()
+
+	mask := syscall.Umask(0o177)
+	defer syscall.Umask(mask)
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,37 @@
+//go:build unix
+
+package apis
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnixUmask(t *testing.T) {
+	mask := syscall.Umask(0o022)
+	defer syscall.Umask(mask)
+
+	path := filepath.Join(t.TempDir(), "sentinel.sock")
+
+	listener, err := listenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the owner can connect until the final mode is set.
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected socket permissions to be %v, got %v", os.FileMode(0o600), perm)
+	}
+
+	if restored := syscall.Umask(0o022); restored != 0o022 {
+		t.Fatalf("expected umask to be restored to %o, got %o", 0o022, restored)
+	}
+}
@@ -0,0 +1,28 @@
+package apis
+
+import (
+	"net/http"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/metrics"
+)
+
+func bindMetricsApi(r *router) {
+	r.get("/metrics", metricsHandler)
+}
+
+// newMetricsRouter creates a router serving only the metrics endpoint,
+// used when metrics are exposed on a dedicated port.
+func newMetricsRouter(app core.App) *router {
+	r := &router{app: app}
+	r.Use(requestID(app))
+	bindMetricsApi(r)
+	return r
+}
+
+func metricsHandler(e *core.EventRequest) error {
+	e.Response.Header().Set("Content-Type", metrics.ContentType)
+	e.Response.WriteHeader(http.StatusOK)
+
+	return e.App.Metrics().Registry.WriteText(e.Response)
+}
@@ -0,0 +1,66 @@
+package apis
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/tests"
+	"github.com/dlbarduzzi/sentinel/tools/metrics"
+)
+
+func TestMetricsApi(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	withTestTokens(t, app)
+
+	router := newRouter(app)
+	router.Use(instrument(app), authenticate(app))
+	bindMetricsApi(router)
+
+	mux := router.buildMux()
+
+	for _, path := range []string{"/api/v1/health", "/api/v1/health", "/missing"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// The authenticated requests are served with a new request context.
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/health", nil)
+	req.Header.Set("Authorization", "Bearer snt_reader")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	app.Metrics().ClusterSyncs.Inc("prod-us", "success")
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code to be %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != metrics.ContentType {
+		t.Fatalf("expected content type to be %q, got %q", metrics.ContentType, ct)
+	}
+
+	expected := []string{
+		`sentinel_http_requests_total{method="GET",route="/api/v1/health",status="200"} 3`,
+		`sentinel_http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`sentinel_http_request_duration_seconds_count{method="GET",route="/api/v1/health"} 3`,
+		`sentinel_http_requests_in_flight 1`,
+		`sentinel_cluster_syncs_total{cluster="prod-us",result="success"} 1`,
+		`sentinel_rule_groups 0`,
+		`go_goroutines `,
+	}
+
+	body := rec.Body.String()
+
+	for _, content := range expected {
+		if !strings.Contains(body, content) {
+			t.Errorf("expected content %v in response body \n%v", content, body)
+		}
+	}
+}
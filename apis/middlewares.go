@@ -0,0 +1,317 @@
+package apis
+
+import (
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/event"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"github.com/dlbarduzzi/sentinel/tools/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestID reads the request id from the incoming headers (or generates
+// a new one), echoes it back in the response and decorates the request
+// context with the id and a request scoped logger.
+func requestID(app core.App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			id := req.Header.Get(event.HeaderRequestID)
+			if !event.IsValidRequestID(id) {
+				id = event.NewRequestID()
+			}
+
+			res.Header().Set(event.HeaderRequestID, id)
+
+			logger := logging.Component(app.Logger(), core.LogComponentHTTP).With(
+				slog.String("request_id", id),
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+			)
+
+			// The trace and span ids of the request span are added by the
+			// logger, also to the records logged without the context.
+			logger = logging.ContextLogger(logger, req.Context())
+
+			ctx := event.RequestIDWithContext(req.Context(), id)
+			ctx = logging.LoggerWithContext(ctx, logger)
+
+			next.ServeHTTP(res, req.WithContext(ctx))
+		})
+	}
+}
+
+// traceRequests starts a server span for every request, continuing the
+// trace of the incoming W3C `traceparent` header. The span is renamed to
+// the matched route by the router.
+func traceRequests(app core.App) func(http.Handler) http.Handler {
+	tracer := app.TracerProvider().Tracer("github.com/dlbarduzzi/sentinel/apis")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			ctx := tracing.Propagator.Extract(req.Context(), propagation.HeaderCarrier(req.Header))
+
+			ctx, span := tracer.Start(ctx, req.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", req.Method),
+					attribute.String("url.path", req.URL.Path),
+					attribute.String("user_agent.original", req.UserAgent()),
+					attribute.String("client.address", remoteIP(req)),
+				),
+			)
+			defer span.End()
+
+			rw := event.NewResponseWriter(res)
+
+			next.ServeHTTP(rw, req.WithContext(ctx))
+
+			status := rw.Status()
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, strconv.Itoa(status))
+			}
+		})
+	}
+}
+
+// Access log fields that can be selected through AccessLogConfig.Fields.
+const (
+	AccessLogFieldMethod    = "method"
+	AccessLogFieldRoute     = "route"
+	AccessLogFieldPath      = "path"
+	AccessLogFieldStatus    = "status"
+	AccessLogFieldBytes     = "bytes"
+	AccessLogFieldLatency   = "latency_ms"
+	AccessLogFieldRemoteIP  = "remote_ip"
+	AccessLogFieldUserAgent = "user_agent"
+	AccessLogFieldRequestID = "request_id"
+)
+
+// DefaultAccessLogFields lists the fields logged when none are configured.
+var DefaultAccessLogFields = []string{
+	AccessLogFieldMethod,
+	AccessLogFieldRoute,
+	AccessLogFieldPath,
+	AccessLogFieldStatus,
+	AccessLogFieldBytes,
+	AccessLogFieldLatency,
+	AccessLogFieldRemoteIP,
+	AccessLogFieldUserAgent,
+	AccessLogFieldRequestID,
+}
+
+// AccessLogConfig defines the access log middleware options.
+type AccessLogConfig struct {
+	// Disabled turns off the access logs entirely.
+	Disabled bool
+
+	// SkipHealthChecks skips successful requests to the health endpoints.
+	SkipHealthChecks bool
+
+	// SkipPaths skips successful requests to the listed url paths.
+	SkipPaths []string
+
+	// SuccessSampleRate is the fraction (0, 1] of successful requests to be
+	// logged. Values outside of this range, such as the zero value, log
+	// every request. Requests with a status >= 400 are always logged.
+	SuccessSampleRate float64
+
+	// Fields selects the logged fields. Defaults to DefaultAccessLogFields.
+	Fields []string
+}
+
+// accessLog writes one structured log entry per request through the app logger.
+func accessLog(app core.App, config AccessLogConfig) func(http.Handler) http.Handler {
+	if config.SuccessSampleRate <= 0 || config.SuccessSampleRate > 1 {
+		config.SuccessSampleRate = 1
+	}
+
+	if len(config.Fields) == 0 {
+		config.Fields = DefaultAccessLogFields
+	}
+
+	skipPaths := slices.Clone(config.SkipPaths)
+	if config.SkipHealthChecks {
+		skipPaths = append(skipPaths, healthPaths...)
+	}
+
+	return func(next http.Handler) http.Handler {
+		if config.Disabled {
+			return next
+		}
+
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			rw := event.NewResponseWriter(res)
+
+			next.ServeHTTP(rw, req)
+
+			status := rw.Status()
+
+			if status < http.StatusBadRequest {
+				if slices.Contains(skipPaths, req.URL.Path) {
+					return
+				}
+				if config.SuccessSampleRate < 1 && rand.Float64() >= config.SuccessSampleRate {
+					return
+				}
+			}
+
+			attrs := make([]slog.Attr, 0, len(config.Fields))
+
+			for _, field := range config.Fields {
+				switch field {
+				case AccessLogFieldMethod:
+					attrs = append(attrs, slog.String(field, req.Method))
+				case AccessLogFieldRoute:
+					attrs = append(attrs, slog.String(field, routePattern(req)))
+				case AccessLogFieldPath:
+					attrs = append(attrs, slog.String(field, req.URL.Path))
+				case AccessLogFieldStatus:
+					attrs = append(attrs, slog.Int(field, status))
+				case AccessLogFieldBytes:
+					attrs = append(attrs, slog.Int64(field, rw.Size()))
+				case AccessLogFieldLatency:
+					latency := float64(time.Since(start).Microseconds()) / 1000
+					attrs = append(attrs, slog.Float64(field, latency))
+				case AccessLogFieldRemoteIP:
+					attrs = append(attrs, slog.String(field, remoteIP(req)))
+				case AccessLogFieldUserAgent:
+					attrs = append(attrs, slog.String(field, req.UserAgent()))
+				case AccessLogFieldRequestID:
+					attrs = append(attrs, slog.String(field, event.RequestIDFromContext(req.Context())))
+				}
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			logger := logging.Component(app.Logger(), core.LogComponentHTTP)
+			logger.LogAttrs(req.Context(), level, "http request", attrs...)
+		})
+	}
+}
+
+// routePattern returns the matched route pattern without its method,
+// e.g. `/api/v1/health`, or an empty string for the unmatched requests.
+// It must be called after the mux served the request.
+func routePattern(req *http.Request) string {
+	matched, ok := req.Context().Value(matchedRouteKey{}).(*matchedRoute)
+	if !ok {
+		return ""
+	}
+
+	pattern := matched.pattern
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		pattern = strings.TrimSpace(pattern[i+1:])
+	}
+	return pattern
+}
+
+func remoteIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// instrument records the http request metrics of every request.
+func instrument(app core.App) func(http.Handler) http.Handler {
+	m := app.Metrics()
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			rw := event.NewResponseWriter(res)
+
+			m.HTTPRequestsFlight.Inc()
+			defer m.HTTPRequestsFlight.Dec()
+
+			next.ServeHTTP(rw, req)
+
+			// Unmatched requests are grouped to keep the label cardinality bounded.
+			route := routePattern(req)
+			if route == "" {
+				route = "unmatched"
+			}
+
+			m.HTTPRequests.Inc(req.Method, route, strconv.Itoa(rw.Status()))
+			m.HTTPRequestDuration.Observe(time.Since(start).Seconds(), req.Method, route)
+		})
+	}
+}
+
+// tokenTouchInterval limits the updates of the token last used time.
+const tokenTouchInterval = time.Minute
+
+// authenticate resolves the `Authorization: Bearer` api token into the
+// identity returned by core.EventRequest.Auth. Requests without the header
+// stay anonymous, while invalid, expired or revoked tokens are rejected.
+func authenticate(app core.App) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			header := req.Header.Get("Authorization")
+			if header == "" {
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			e := &core.EventRequest{
+				App:   app,
+				Event: event.Event{Request: req, Response: res},
+			}
+
+			scheme, raw, _ := strings.Cut(header, " ")
+			if !strings.EqualFold(scheme, "Bearer") || raw == "" {
+				handleError(e, e.UnauthorizedError("The authorization header must be a bearer token."))
+				return
+			}
+
+			ctx := req.Context()
+			store := app.TokenStore()
+
+			token, err := store.FindTokenByHash(ctx, core.HashToken(strings.TrimSpace(raw)))
+			if err != nil && !errors.Is(err, core.ErrNotFound) {
+				handleError(e, err)
+				return
+			}
+
+			now := time.Now().UTC()
+
+			if token == nil || token.Expired(now) {
+				handleError(e, e.UnauthorizedError("The token is invalid, expired or revoked."))
+				return
+			}
+
+			if now.Sub(token.LastUsedAt) >= tokenTouchInterval {
+				if err := store.TouchToken(ctx, token.ID, now); err != nil {
+					e.Logger().Warn("failed to update token last used time", slog.String("error", err.Error()))
+				}
+				token.LastUsedAt = now
+			}
+
+			logger := logging.LoggerFromContextOr(ctx, app.Logger()).With(slog.String("auth_token_id", token.ID))
+
+			ctx = core.TokenWithContext(ctx, token)
+			ctx = logging.LoggerWithContext(ctx, logger)
+
+			next.ServeHTTP(res, req.WithContext(ctx))
+		})
+	}
+}
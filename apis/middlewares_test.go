@@ -0,0 +1,330 @@
+package apis
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tests"
+	"github.com/dlbarduzzi/sentinel/tools/event"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"github.com/dlbarduzzi/sentinel/tools/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	router := newRouter(app)
+
+	var requestID string
+	var hasLogger bool
+
+	router.get("/test", func(e *core.EventRequest) error {
+		requestID = e.RequestID()
+		hasLogger = e.Logger() != app.Logger()
+		return e.NotFoundError("")
+	})
+
+	mux := router.buildMux()
+
+	testCases := []struct {
+		name       string
+		header     string
+		expectSame bool
+	}{
+		{"generated id", "", false},
+		{"client id", "client-id-123", true},
+		{"invalid client id", "invalid id", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+
+			if tc.header != "" {
+				req.Header.Set(event.HeaderRequestID, tc.header)
+			}
+
+			mux.ServeHTTP(rec, req)
+
+			header := rec.Header().Get(event.HeaderRequestID)
+
+			if !event.IsValidRequestID(header) {
+				t.Fatalf("expected valid request id header, got %q", header)
+			}
+
+			if header != requestID {
+				t.Fatalf("expected request id to be %q, got %q", header, requestID)
+			}
+
+			if tc.expectSame && header != tc.header {
+				t.Fatalf("expected request id to be %q, got %q", tc.header, header)
+			}
+
+			if !tc.expectSame && header == tc.header {
+				t.Fatalf("expected request id %q to be replaced", tc.header)
+			}
+
+			if !hasLogger {
+				t.Fatal("expected request scoped logger to be set")
+			}
+
+			content := `"request_id":"` + requestID + `"`
+			if body := rec.Body.String(); !strings.Contains(body, content) {
+				t.Fatalf("expected content %v in response body \n%v", content, body)
+			}
+		})
+	}
+}
+
+// bufferedApp overrides the test app logger to capture its output.
+type bufferedApp struct {
+	*tests.TestApp
+	logger *slog.Logger
+}
+
+func (app *bufferedApp) Logger() *slog.Logger {
+	return app.logger
+}
+
+func newBufferedApp(t *testing.T) (*bufferedApp, *bytes.Buffer) {
+	t.Helper()
+
+	testApp, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	buf := new(bytes.Buffer)
+
+	return &bufferedApp{
+		TestApp: testApp,
+		logger:  slog.New(slog.NewJSONHandler(buf, nil)),
+	}, buf
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	testCases := []struct {
+		name            string
+		config          AccessLogConfig
+		path            string
+		token           string
+		expectedLogs    int
+		expectedContent []string
+	}{
+		{
+			name:         "default config",
+			config:       AccessLogConfig{},
+			path:         "/api/v1/health",
+			expectedLogs: 1,
+			expectedContent: []string{
+				`"msg":"http request"`,
+				`"method":"GET"`,
+				`"route":"/api/v1/health"`,
+				`"status":200`,
+				`"user_agent":"sentinel-test"`,
+				`"request_id":"test-id"`,
+			},
+		},
+		{
+			name:         "authenticated request",
+			config:       AccessLogConfig{},
+			path:         "/api/v1/health",
+			token:        "snt_reader",
+			expectedLogs: 1,
+			expectedContent: []string{
+				`"route":"/api/v1/health"`,
+				`"status":200`,
+			},
+		},
+		{
+			name:         "disabled",
+			config:       AccessLogConfig{Disabled: true},
+			path:         "/api/v1/health",
+			expectedLogs: 0,
+		},
+		{
+			name:         "skip health checks",
+			config:       AccessLogConfig{SkipHealthChecks: true},
+			path:         "/api/v1/health",
+			expectedLogs: 0,
+		},
+		{
+			name:         "selected fields",
+			config:       AccessLogConfig{Fields: []string{AccessLogFieldStatus}},
+			path:         "/api/v1/health",
+			expectedLogs: 1,
+			expectedContent: []string{
+				`"status":200`,
+			},
+		},
+		{
+			name:         "failures are always logged",
+			config:       AccessLogConfig{SkipPaths: []string{"/failure"}, SuccessSampleRate: 0.0001},
+			path:         "/failure",
+			expectedLogs: 1,
+			expectedContent: []string{
+				`"level":"ERROR"`,
+				`"route":"/failure"`,
+				`"status":500`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app, buf := newBufferedApp(t)
+			withTestTokens(t, app.TestApp)
+
+			router := newRouter(app)
+			router.Use(accessLog(app, tc.config), authenticate(app))
+
+			router.get("/failure", func(*core.EventRequest) error {
+				return errors.New("unexpected failure")
+			})
+
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set("User-Agent", "sentinel-test")
+			req.Header.Set(event.HeaderRequestID, "test-id")
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			router.buildMux().ServeHTTP(rec, req)
+
+			var logs []string
+			for line := range strings.SplitSeq(buf.String(), "\n") {
+				if strings.Contains(line, `"msg":"http request"`) {
+					logs = append(logs, line)
+				}
+			}
+
+			if len(logs) != tc.expectedLogs {
+				t.Fatalf("expected %d access logs, got %d \n%v", tc.expectedLogs, len(logs), buf.String())
+			}
+
+			for _, content := range tc.expectedContent {
+				if !strings.Contains(logs[0], content) {
+					t.Errorf("expected content %v in access log \n%v", content, logs[0])
+				}
+			}
+
+			if slices.Contains(tc.config.Fields, AccessLogFieldStatus) && strings.Contains(logs[0], `"method"`) {
+				t.Errorf("expected access log to contain only selected fields \n%v", logs[0])
+			}
+		})
+	}
+}
+
+func TestTraceRequestsMiddleware(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	provider, err := tracing.NewProvider(tracing.Config{SpanExporter: exporter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := tests.NewTestAppWithConfig(core.BaseAppConfig{
+		LogDisabled:    true,
+		TracerProvider: provider,
+	})
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	router := newRouter(app)
+
+	router.get("/failure/{id}", func(*core.EventRequest) error {
+		return errors.New("unexpected failure")
+	})
+
+	mux := router.buildMux()
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentID := "00f067aa0ba902b7"
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/failure/1", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+
+	mux.ServeHTTP(rec, req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+
+	if span.Name != "GET /failure/{id}" {
+		t.Fatalf("expected span name to be %q, got %q", "GET /failure/{id}", span.Name)
+	}
+
+	if span.SpanKind != trace.SpanKindServer {
+		t.Fatalf("expected server span, got %v", span.SpanKind)
+	}
+
+	if span.SpanContext.TraceID().String() != traceID || span.Parent.SpanID().String() != parentID {
+		t.Fatalf("expected span to continue the incoming trace, got %v", span.SpanContext)
+	}
+
+	if span.Status.Code != codes.Error || len(span.Events) != 1 {
+		t.Fatalf("expected span to record the error, got %+v", span.Status)
+	}
+
+	attrs := map[attribute.Key]attribute.Value{}
+	for _, attr := range span.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+
+	if route := attrs["http.route"].AsString(); route != "/failure/{id}" {
+		t.Fatalf("expected http.route to be %q, got %q", "/failure/{id}", route)
+	}
+
+	if status := attrs["http.response.status_code"].AsInt64(); status != http.StatusInternalServerError {
+		t.Fatalf("expected status code to be %d, got %d", http.StatusInternalServerError, status)
+	}
+}
+
+func TestRequestLoggerTraceIDs(t *testing.T) {
+	app, buf := newBufferedApp(t)
+
+	// The trace ids are added by the handler of the app loggers.
+	app.logger = logging.NewLoggerWithConfig(logging.Config{Format: logging.FormatJson, Writer: buf})
+
+	router := newRouter(app)
+
+	router.get("/test", func(e *core.EventRequest) error {
+		e.Logger().Info("test")
+		return e.Status(http.StatusOK)
+	})
+
+	traceID := "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+
+	router.buildMux().ServeHTTP(rec, req)
+
+	// The no-op tracer propagates the incoming span context.
+	content := `"trace_id":"` + traceID + `"`
+	if !strings.Contains(buf.String(), content) {
+		t.Fatalf("expected content %v in logs \n%v", content, buf.String())
+	}
+}
@@ -1,38 +1,148 @@
 package apis
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/dlbarduzzi/sentinel/core"
 	"github.com/dlbarduzzi/sentinel/tools/event"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// Ensures that the router implements the core.Router interface.
+var _ core.Router = (*router)(nil)
+
 type route struct {
 	pattern string
-	handler func(*core.EventRequest)
+	handler func(*core.EventRequest) error
 }
 
 type router struct {
-	app    core.App
-	routes []route
+	app         core.App
+	routes      []route
+	middlewares []func(http.Handler) http.Handler
+
+	// scope is required by the routes added with Route, when not empty.
+	scope string
 }
 
+// newRouter creates the router of the public listeners, serving the
+// health endpoints and the routes added by the app.
 func newRouter(app core.App) *router {
 	r := &router{app: app}
+	r.Use(traceRequests(app), requestID(app))
 	bindHealthApi(r)
 	return r
 }
 
-func (r *router) add(pattern string, handler func(*core.EventRequest)) {
+// newAdminRouter creates the router of the admin listeners. The admin and
+// api tokens endpoints are never bound to the public router, and require
+// the admin scope like the routes added by the app.
+func newAdminRouter(app core.App) *router {
+	r := &router{app: app, scope: core.ScopeAdmin}
+	r.Use(traceRequests(app), requestID(app))
+	bindHealthApi(r)
+	bindAdminApi(r)
+	bindTokensApi(r)
+	return r
+}
+
+// with returns a copy of the router with extra middlewares, wrapping the
+// routes inside the existing ones.
+func (r *router) with(middlewares ...func(http.Handler) http.Handler) *router {
+	return &router{
+		app:         r.app,
+		routes:      slices.Clone(r.routes),
+		middlewares: append(slices.Clone(r.middlewares), middlewares...),
+		scope:       r.scope,
+	}
+}
+
+// Use registers middlewares wrapping every route. The first registered
+// middleware is the outermost one.
+func (r *router) Use(middlewares ...func(http.Handler) http.Handler) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// add registers a route, or returns an error if http.ServeMux would
+// reject its pattern when building the mux, e.g. a duplicated or
+// conflicting pattern.
+func (r *router) add(pattern string, handler func(*core.EventRequest) error) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("invalid route %q - %v", pattern, rec)
+		}
+	}()
+
+	// ServeMux validates the patterns as they are registered.
+	mux := http.NewServeMux()
+	for _, route := range r.routes {
+		mux.HandleFunc(route.pattern, http.NotFound)
+	}
+	mux.HandleFunc(pattern, http.NotFound)
+
 	r.routes = append(r.routes, route{
 		pattern: pattern,
 		handler: handler,
 	})
+
+	return nil
+}
+
+// mustAdd registers one of the Sentinel routes, panicking if invalid.
+func (r *router) mustAdd(pattern string, handler func(*core.EventRequest) error) {
+	if err := r.add(pattern, handler); err != nil {
+		panic(err)
+	}
 }
 
-func (r *router) get(pattern string, handler func(*core.EventRequest)) {
-	r.add(fmt.Sprintf("GET %s", pattern), handler)
+// Route registers a handler for the given http method and path. It returns
+// an error if the method is not a valid http method token, if the path
+// does not start with a slash, or if the pattern is invalid or conflicts
+// with an already registered route. The routes of the admin router
+// require the admin scope.
+func (r *router) Route(method string, path string, handler func(*core.EventRequest) error) error {
+	if method == "" || strings.IndexFunc(method, func(c rune) bool { return !isTokenChar(c) }) >= 0 {
+		return fmt.Errorf("invalid route method %q", method)
+	}
+
+	// ServeMux would read the first path segment as a host.
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("invalid route path %q, it must start with a slash", path)
+	}
+
+	if r.scope != "" {
+		handler = RequireScope(r.scope, handler)
+	}
+
+	return r.add(fmt.Sprintf("%s %s", method, path), handler)
+}
+
+func (r *router) get(pattern string, handler func(*core.EventRequest) error) {
+	r.mustAdd(fmt.Sprintf("GET %s", pattern), handler)
+}
+
+func (r *router) post(pattern string, handler func(*core.EventRequest) error) {
+	r.mustAdd(fmt.Sprintf("POST %s", pattern), handler)
+}
+
+func (r *router) put(pattern string, handler func(*core.EventRequest) error) {
+	r.mustAdd(fmt.Sprintf("PUT %s", pattern), handler)
+}
+
+func (r *router) delete(pattern string, handler func(*core.EventRequest) error) {
+	r.mustAdd(fmt.Sprintf("DELETE %s", pattern), handler)
+}
+
+// isTokenChar reports whether c is allowed in an http method, as defined
+// by the token rule of RFC 9110.
+func isTokenChar(c rune) bool {
+	return c < 0x7f && (c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.ContainsRune("!#$%&'*+-.^_`|~", c))
 }
 
 func (r *router) buildMux() http.Handler {
@@ -40,15 +150,68 @@ func (r *router) buildMux() http.Handler {
 
 	for _, route := range r.routes {
 		mux.HandleFunc(route.pattern, func(res http.ResponseWriter, req *http.Request) {
-			route.handler(&core.EventRequest{
+			if matched, ok := req.Context().Value(matchedRouteKey{}).(*matchedRoute); ok {
+				matched.pattern = route.pattern
+			}
+
+			span := trace.SpanFromContext(req.Context())
+			span.SetName(route.pattern)
+			span.SetAttributes(attribute.String("http.route", routePattern(req)))
+
+			// The writer tells handleError whether a response was written.
+			rw, ok := res.(*event.ResponseWriter)
+			if !ok {
+				rw = event.NewResponseWriter(res)
+			}
+
+			e := &core.EventRequest{
 				App: r.app,
 				Event: event.Event{
 					Request:  req,
-					Response: res,
+					Response: rw,
 				},
-			})
+			}
+
+			defer func() {
+				if rec := recover(); rec != nil {
+					// Let the server abort the response as intended.
+					if rec == http.ErrAbortHandler {
+						panic(rec)
+					}
+					internalServerError(e, fmt.Errorf("recovered from panic: %v", rec))
+				}
+			}()
+
+			if err := r.app.OnRequest().Trigger(e, route.handler); err != nil {
+				handleError(e, err)
+			}
 		})
 	}
 
-	return mux
+	var handler http.Handler = mux
+
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		handler = r.middlewares[i](handler)
+	}
+
+	return withMatchedRoute(handler)
+}
+
+type matchedRouteKey struct{}
+
+// matchedRoute holds the pattern of the route served by the mux. The
+// middlewares replacing the request context, e.g. authenticate, hide the
+// request seen by the mux from the middlewares around them, which read the
+// pattern through this holder instead.
+type matchedRoute struct {
+	pattern string
+}
+
+// withMatchedRoute stores an empty matchedRoute in the request context
+// before the middlewares run.
+func withMatchedRoute(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		ctx := context.WithValue(req.Context(), matchedRouteKey{}, &matchedRoute{})
+		next.ServeHTTP(res, req.WithContext(ctx))
+	})
 }
@@ -1,8 +1,11 @@
 package apis
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/dlbarduzzi/sentinel/core"
@@ -20,16 +23,19 @@ func TestNewRouter(t *testing.T) {
 	// The calls to be made by each registered endpoint.
 	calls := ""
 
-	router.get("/a", func(*core.EventRequest) {
+	router.get("/a", func(*core.EventRequest) error {
 		calls += "a"
+		return nil
 	})
 
-	router.get("/b", func(*core.EventRequest) {
+	router.get("/b", func(*core.EventRequest) error {
 		calls += "b"
+		return nil
 	})
 
-	router.get("/a/b", func(*core.EventRequest) {
+	router.get("/a/b", func(*core.EventRequest) error {
 		calls += "a_b"
+		return nil
 	})
 
 	mux := router.buildMux()
@@ -69,3 +75,203 @@ func TestNewRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterRoute(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	handler := func(e *core.EventRequest) error {
+		return e.Status(http.StatusOK)
+	}
+
+	router := newRouter(app)
+
+	if err := router.Route(http.MethodGet, "/api/v1/items/{id}", handler); err != nil {
+		t.Fatalf("expected a valid route to be registered, got %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{"empty method", "", "/api/v1/other"},
+		{"invalid method", "GET POST", "/api/v1/other"},
+		{"invalid method character", "GE(T", "/api/v1/other"},
+		{"invalid path", http.MethodGet, "api/v1/other"},
+		{"invalid wildcard", http.MethodGet, "/api/v1/{id"},
+		{"duplicated route", http.MethodGet, "/api/v1/items/{id}"},
+		{"conflicting route", http.MethodGet, "/api/v1/items/{name}"},
+		{"duplicated sentinel route", http.MethodGet, "/api/v1/health"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := router.Route(tc.method, tc.path, handler); err == nil {
+				t.Fatalf("expected route %q %q to be rejected", tc.method, tc.path)
+			}
+		})
+	}
+
+	// The rejected routes are not registered, the mux still builds.
+	router.buildMux()
+}
+
+func TestAdminRouterRoute(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	saveTestToken(t, app, "snt_admin", 0, core.ScopeAdmin)
+	saveTestToken(t, app, "snt_reader", 0, core.ScopeRulesRead)
+
+	router := newAdminRouter(app)
+	router.Use(authenticate(app))
+
+	err = router.Route(http.MethodGet, "/api/v1/admin/custom", func(e *core.EventRequest) error {
+		return e.Status(http.StatusOK)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := router.buildMux()
+
+	testCases := []struct {
+		token          string
+		expectedStatus int
+	}{
+		{"", http.StatusUnauthorized},
+		{"snt_reader", http.StatusForbidden},
+		{"snt_admin", http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/custom", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tc.expectedStatus {
+			t.Fatalf("expected the custom admin route with token %q to be %d, got %d", tc.token, tc.expectedStatus, rec.Code)
+		}
+	}
+}
+
+func TestRouterErrors(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	router := newRouter(app)
+
+	router.get("/not-found", func(*core.EventRequest) error {
+		return fmt.Errorf("failed to find item: %w", core.ErrNotFound)
+	})
+
+	router.get("/forbidden", func(e *core.EventRequest) error {
+		return e.ForbiddenError("")
+	})
+
+	router.get("/failure", func(*core.EventRequest) error {
+		return errors.New("unexpected failure")
+	})
+
+	router.get("/panic", func(*core.EventRequest) error {
+		panic("unexpected panic")
+	})
+
+	mux := router.buildMux()
+
+	testCases := []struct {
+		path           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"/not-found", http.StatusNotFound, `"code":"NOT_FOUND"`},
+		{"/forbidden", http.StatusForbidden, `"code":"FORBIDDEN"`},
+		{"/failure", http.StatusInternalServerError, `"code":"INTERNAL_SERVER_ERROR"`},
+		{"/panic", http.StatusInternalServerError, `"code":"INTERNAL_SERVER_ERROR"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status code to be %d, got %d", tc.expectedStatus, rec.Code)
+			}
+
+			if body := rec.Body.String(); !strings.Contains(body, tc.expectedCode) {
+				t.Fatalf("expected content %v in response body \n%v", tc.expectedCode, body)
+			}
+		})
+	}
+}
+
+func TestRouterOnRequest(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	calls := ""
+
+	app.OnRequest().BindFunc(func(e *core.EventRequest) error {
+		calls += "a"
+		if e.Request.URL.Path == "/blocked" {
+			return e.ForbiddenError("")
+		}
+		return e.Next()
+	})
+
+	router := newRouter(app)
+
+	router.get("/allowed", func(*core.EventRequest) error {
+		calls += "b"
+		return nil
+	})
+
+	router.get("/blocked", func(*core.EventRequest) error {
+		calls += "c"
+		return nil
+	})
+
+	mux := router.buildMux()
+
+	testCases := []struct {
+		path           string
+		calls          string
+		expectedStatus int
+	}{
+		{"/allowed", "ab", http.StatusOK},
+		{"/blocked", "a", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.path, func(t *testing.T) {
+			calls = "" // reset
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			if calls != tc.calls {
+				t.Fatalf("expected calls to be %q, got %q", tc.calls, calls)
+			}
+
+			if rec.Code != tc.expectedStatus {
+				t.Fatalf("expected status code to be %d, got %d", tc.expectedStatus, rec.Code)
+			}
+		})
+	}
+}
@@ -5,30 +5,94 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"slices"
 	"time"
 
 	"github.com/dlbarduzzi/sentinel/core"
 )
 
 const (
-	DefaultServerPort         = 8090
-	DefaultServerIdleTimeout  = time.Second * 5
-	DefaultServerReadTimeout  = time.Second * 5
-	DefaultServerWriteTimeout = time.Second * 5
+	DefaultServerPort          = 8090
+	DefaultServerIdleTimeout   = time.Second * 5
+	DefaultServerReadTimeout   = time.Second * 5
+	DefaultServerWriteTimeout  = time.Second * 5
+	DefaultShutdownGracePeriod = time.Second * 30
+	DefaultTerminateTimeout    = time.Second * 10
+
+	// DefaultServerReadHeaderTimeout bounds the time to read the request
+	// headers, protecting the server from slowloris attacks.
+	DefaultServerReadHeaderTimeout = time.Second * 5
 )
 
 type ServeConfig struct {
+	// Listener accepts the api connections instead of listening on Port,
+	// e.g. a listener bound to `127.0.0.1:0` in tests.
+	Listener net.Listener
+
 	Port         int
 	IdleTimeout  time.Duration
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	AccessLog    AccessLogConfig
+
+	// ReadHeaderTimeout defaults to DefaultServerReadHeaderTimeout.
+	ReadHeaderTimeout time.Duration
+
+	// MaxHeaderBytes limits the size of the request headers, defaults to
+	// http.DefaultMaxHeaderBytes.
+	MaxHeaderBytes int
+
+	// H2C serves HTTP/2 without TLS (prior knowledge) next to HTTP/1, e.g.
+	// behind a service mesh terminating TLS.
+	H2C bool
+
+	// KeepAlivesDisabled closes the connections after every request.
+	KeepAlivesDisabled bool
+
+	// MaxConnections limits the connections open at once on the public
+	// listeners, when greater than 0. Accepting new connections waits
+	// while the limit is reached. The admin and metrics listeners are
+	// not limited, so they stay reachable under load.
+	MaxConnections int
+
+	// MetricsDisabled turns off the `/metrics` endpoint.
+	MetricsDisabled bool
+
+	// MetricsPort serves the `/metrics` endpoint on a dedicated port
+	// instead of the api port when greater than 0. The metrics server
+	// is always served over plain http.
+	MetricsPort int
+
+	// TLS serves the api over https when enabled.
+	TLS TLSConfig
+
+	// Listeners are additional listeners, each with its own router, e.g.
+	// the admin endpoints on `127.0.0.1:9090` or the public routes on a
+	// Unix socket for a local agent.
+	Listeners []ListenerConfig
+
+	// ShutdownGracePeriod bounds the time spent draining the in-flight
+	// requests and the background jobs. Defaults to
+	// DefaultShutdownGracePeriod.
+	ShutdownGracePeriod time.Duration
+
+	// TerminateTimeout bounds the OnTerminate hooks, which run after the
+	// grace period even when it was used up. Defaults to
+	// DefaultTerminateTimeout.
+	TerminateTimeout time.Duration
+
+	// PreShutdownDelay keeps serving requests after the Serve context is done,
+	// while the readiness probe fails, so the load balancers deregister
+	// the instance before it stops accepting connections.
+	PreShutdownDelay time.Duration
 }
 
-func Serve(app core.App, config ServeConfig) error {
+// Serve starts the api server and blocks until ctx is done, then shuts
+// the server and the app down gracefully. Handling the process signals is
+// left to the caller, e.g. with signal.NotifyContext.
+func Serve(ctx context.Context, app core.App, config ServeConfig) error {
 	if config.Port < 1 {
 		config.Port = DefaultServerPort
 	}
@@ -45,54 +109,279 @@ func Serve(app core.App, config ServeConfig) error {
 		config.WriteTimeout = DefaultServerWriteTimeout
 	}
 
+	if config.ReadHeaderTimeout < 1 {
+		config.ReadHeaderTimeout = DefaultServerReadHeaderTimeout
+	}
+
+	if config.ShutdownGracePeriod < 1 {
+		config.ShutdownGracePeriod = DefaultShutdownGracePeriod
+	}
+
+	// The requests rejected by authenticate are still logged and measured.
 	router := newRouter(app)
+	router.Use(accessLog(app, config.AccessLog), instrument(app), authenticate(app))
+
+	adminRouter := newAdminRouter(app)
+	adminRouter.Use(accessLog(app, config.AccessLog), instrument(app), authenticate(app))
+
+	// The metrics are served next to the admin endpoints when there is
+	// an admin listener, and with the public routes otherwise.
+	if !config.MetricsDisabled && config.MetricsPort < 1 {
+		if slices.ContainsFunc(config.Listeners, func(l ListenerConfig) bool { return l.Admin }) {
+			bindMetricsApi(adminRouter)
+		} else {
+			bindMetricsApi(router)
+		}
+	}
+
+	server := newServer(config, fmt.Sprintf(":%d", config.Port))
+
+	if config.TLS.Enabled() {
+		tlsConfig, err := newTLSConfig(app, config.TLS)
+		if err != nil {
+			return fmt.Errorf("invalid tls config - %w", err)
+		}
+		server.TLSConfig = tlsConfig
+	}
+
+	serveEvent := &core.ServeEvent{
+		App:         app,
+		Router:      router,
+		AdminRouter: adminRouter,
+		Server:      server,
+	}
+
+	return app.OnServe().Trigger(serveEvent, func(e *core.ServeEvent) error {
+		// Build the handler only now so the routes registered by the
+		// OnServe hooks are included.
+		if e.Server.Handler == nil {
+			e.Server.Handler = router.buildMux()
+		}
+
+		servers := []*listenerServer{{
+			name:     "public",
+			server:   e.Server,
+			listener: config.Listener,
+			limited:  true,
+		}}
+
+		for _, lc := range config.Listeners {
+			r, name := router, "public"
+			if lc.Admin {
+				r, name = adminRouter, "admin"
+			}
+
+			if lc.Name != "" {
+				name = lc.Name
+			}
+
+			ls := &listenerServer{
+				name:     name,
+				server:   newServer(config, lc.Addr),
+				listener: lc.Listener,
+				limited:  !lc.Admin,
+			}
+			ls.server.Handler = r.with(lc.Middlewares...).buildMux()
+
+			if lc.TLS.Enabled() {
+				tlsConfig, err := newTLSConfig(app, lc.TLS)
+				if err != nil {
+					return fmt.Errorf("invalid %s listener tls config - %w", name, err)
+				}
+				ls.server.TLSConfig = tlsConfig
+			}
+
+			servers = append(servers, ls)
+		}
+
+		if !config.MetricsDisabled && config.MetricsPort > 0 {
+			ls := &listenerServer{
+				name:   "metrics",
+				server: newServer(config, fmt.Sprintf(":%d", config.MetricsPort)),
+			}
+			ls.server.Handler = newMetricsRouter(app).buildMux()
+
+			servers = append(servers, ls)
+		}
 
+		// Every listener is bound before serving, so a taken address
+		// fails the start instead of a single listener.
+		for _, ls := range servers {
+			if ls.listener != nil {
+				continue
+			}
+
+			listener, err := listen(ls.server.Addr)
+			if err != nil {
+				// The listeners supplied by the caller are left open.
+				for _, bound := range servers {
+					if bound.owned {
+						_ = bound.listener.Close()
+					}
+				}
+				return fmt.Errorf("failed to listen on %q - %w", ls.server.Addr, err)
+			}
+
+			ls.listener = listener
+			ls.owned = true
+		}
+
+		if config.MaxConnections > 0 {
+			// The limit is shared by all the public listeners.
+			connections := make(chan struct{}, config.MaxConnections)
+
+			for _, ls := range servers {
+				if ls.limited {
+					ls.listener = newLimitListener(ls.listener, connections)
+				}
+			}
+		}
+
+		serveErr := make(chan error, len(servers))
+
+		for _, ls := range servers {
+			app.Logger().Info("server starting",
+				slog.String("listener", ls.name),
+				slog.String("addr", ls.listener.Addr().String()),
+				slog.Bool("tls", ls.server.TLSConfig != nil),
+			)
+
+			go func() {
+				serveErr <- ls.serve()
+			}()
+		}
+
+		httpServers := make([]*http.Server, 0, len(servers))
+		for _, ls := range servers {
+			httpServers = append(httpServers, ls.server)
+		}
+
+		select {
+		case err := <-serveErr:
+			if errors.Is(err, http.ErrServerClosed) {
+				err = nil
+			} else {
+				app.Logger().Error("server failed, shutting down", slog.String("error", err.Error()))
+			}
+
+			// The other listeners, the background jobs and the OnTerminate
+			// hooks are stopped like on a regular shutdown, without waiting
+			// for the load balancers.
+			config.PreShutdownDelay = 0
+
+			if shutdownErr := shutdown(app, config, httpServers...); shutdownErr != nil {
+				err = errors.Join(err, shutdownErr)
+			}
+
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			app.Logger().Info("server shutting down")
+
+			if err := shutdown(app, config, httpServers...); err != nil {
+				return err
+			}
+		}
+
+		app.Logger().Info("server stopped")
+
+		return nil
+	})
+}
+
+// listenerServer is an http server with its listener.
+type listenerServer struct {
+	name     string
+	server   *http.Server
+	listener net.Listener
+
+	// limited counts the connections against ServeConfig.MaxConnections.
+	limited bool
+
+	// owned is set for the listeners bound by Serve, as opposed to the
+	// ones supplied in the config.
+	owned bool
+}
+
+func (ls *listenerServer) serve() error {
+	if ls.server.TLSConfig != nil {
+		// The certificates are provided by the tls config.
+		return ls.server.ServeTLS(ls.listener, "", "")
+	}
+	return ls.server.Serve(ls.listener)
+}
+
+func newServer(config ServeConfig, addr string) *http.Server {
 	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", config.Port),
-		Handler:      router.buildMux(),
-		IdleTimeout:  config.IdleTimeout,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
+		Addr:              addr,
+		IdleTimeout:       config.IdleTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+
+	if config.H2C {
+		// HTTP/2 over TLS stays negotiated through ALPN.
+		server.Protocols = new(http.Protocols)
+		server.Protocols.SetHTTP1(true)
+		server.Protocols.SetHTTP2(true)
+		server.Protocols.SetUnencryptedHTTP2(true)
 	}
 
-	shutdownErr := make(chan error)
+	server.SetKeepAlivesEnabled(!config.KeepAlivesDisabled)
+
+	return server
+}
+
+// shutdown gracefully stops the servers. The readiness probe fails for the
+// pre-shutdown delay, then the in-flight requests and the background jobs
+// are drained within the grace period. The OnTerminate hooks run last with
+// their own timeout, so they still get to flush and close the resources
+// when the grace period is used up.
+func shutdown(app core.App, config ServeConfig, servers ...*http.Server) error {
+	if config.TerminateTimeout < 1 {
+		config.TerminateTimeout = DefaultTerminateTimeout
+	}
 
-	go func() {
-		quit := make(chan os.Signal, 1)
-		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	// Fail readiness so load balancers stop routing new traffic.
+	app.HealthChecks().SetShuttingDown(true)
 
-		inSignal := <-quit
-		app.Logger().Info("server received shutdown signal",
-			slog.String("signal", inSignal.String()),
+	if config.PreShutdownDelay > 0 {
+		app.Logger().Info("server waiting before shutdown",
+			slog.Duration("delay", config.PreShutdownDelay),
 		)
+		time.Sleep(config.PreShutdownDelay)
+	}
 
-		// Finish running jobs before the server shuts down.
-		app.OnShutdown()
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownGracePeriod)
+	defer cancel()
 
-		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
-		defer cancel()
+	var errs []error
 
-		err := server.Shutdown(ctx)
-		if err != nil {
-			shutdownErr <- err
+	for _, server := range servers {
+		if server == nil {
+			continue
 		}
 
-		shutdownErr <- nil
-	}()
-
-	app.Logger().Info("server starting", slog.Int("port", config.Port))
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown server %q - %w", server.Addr, err))
 
-	err := server.ListenAndServe()
-	if !errors.Is(err, http.ErrServerClosed) {
-		return err
+			// Abort the requests still running after the grace period.
+			errs = append(errs, server.Close())
+		}
 	}
 
-	err = <-shutdownErr
-	if err != nil {
-		return err
+	if err := app.Drain(ctx); err != nil {
+		app.Logger().Error("failed to drain background jobs", slog.String("error", err.Error()))
 	}
 
-	app.Logger().Info("server stopped")
+	terminateCtx, terminateCancel := context.WithTimeout(context.Background(), config.TerminateTimeout)
+	defer terminateCancel()
+
+	// Failures are already reported by the app logger.
+	_ = app.Terminate(terminateCtx)
 
-	return nil
+	return errors.Join(errs...)
 }
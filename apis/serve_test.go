@@ -2,12 +2,21 @@ package apis
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
+	"net"
+	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/dlbarduzzi/sentinel/core"
 	"github.com/dlbarduzzi/sentinel/tests"
 )
 
@@ -16,10 +25,20 @@ type apiTestScenario struct {
 	url             string
 	method          string
 	body            io.Reader
+	headers         map[string]string
 	expectedStatus  int
 	expectedContent []string
+	beforeTest      func(t *testing.T, app *tests.TestApp)
+
+	// admin serves the request with the router of the admin listeners,
+	// authenticated with the adminTestToken unless the headers set an
+	// Authorization header.
+	admin bool
 }
 
+// adminTestToken has the admin scope in the admin scenarios.
+const adminTestToken = "snt_admin_listener"
+
 func (s *apiTestScenario) Test(t *testing.T) {
 	t.Run(s.name, func(t *testing.T) {
 		s.test(t)
@@ -32,7 +51,21 @@ func (s *apiTestScenario) test(t *testing.T) {
 		t.Fatalf("failed to initialize test app instance - %v", err)
 	}
 
+	if s.admin {
+		saveTestToken(t, app, adminTestToken, 0, core.ScopeAdmin)
+	}
+
+	if s.beforeTest != nil {
+		s.beforeTest(t, app)
+	}
+
 	router := newRouter(app)
+	if s.admin {
+		router = newAdminRouter(app)
+	}
+
+	// Authenticates the requests as wired by Serve.
+	router.Use(authenticate(app))
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(s.method, s.url, s.body)
@@ -40,6 +73,14 @@ func (s *apiTestScenario) test(t *testing.T) {
 	// Set default header.
 	req.Header.Set("Content-Type", "application/json")
 
+	if _, ok := s.headers["Authorization"]; s.admin && !ok {
+		req.Header.Set("Authorization", "Bearer "+adminTestToken)
+	}
+
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+
 	mux := router.buildMux()
 	mux.ServeHTTP(rec, req)
 
@@ -82,3 +123,580 @@ func (s *apiTestScenario) test(t *testing.T) {
 		}
 	}
 }
+
+// testClient does not keep idle connections, which would delay the
+// shutdown of the test servers.
+var testClient = &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServe(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var terminated bool
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		terminated = true
+		return e.Next()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- Serve(ctx, app, ServeConfig{Listener: listener, MetricsDisabled: true})
+	}()
+
+	res, err := testClient.Get("http://" + listener.Addr().String() + "/api/v1/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status code to be %d, got %d", http.StatusOK, res.StatusCode)
+	}
+
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("expected nil error, got %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected Serve to return once the context is canceled")
+	}
+
+	if !terminated {
+		t.Fatal("expected OnTerminate to be triggered")
+	}
+
+	if _, err := testClient.Get("http://" + listener.Addr().String() + "/api/v1/health"); err == nil {
+		t.Fatal("expected the listener to be closed")
+	}
+}
+
+func TestServeListeners(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		err := e.Router.Route(http.MethodGet, "/api/v1/public", func(e *core.EventRequest) error {
+			return e.Status(http.StatusOK)
+		})
+		if err != nil {
+			return err
+		}
+		err = e.AdminRouter.Route(http.MethodGet, "/api/v1/admin/custom", func(e *core.EventRequest) error {
+			return e.Status(http.StatusOK)
+		})
+		if err != nil {
+			return err
+		}
+		return e.Next()
+	})
+
+	public, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "sentinel.sock")
+
+	var socketRequests int
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- Serve(ctx, app, ServeConfig{
+			Listener: public,
+			Listeners: []ListenerConfig{
+				{Name: "agent", Addr: "unix:" + socket, Middlewares: []func(http.Handler) http.Handler{
+					func(next http.Handler) http.Handler {
+						return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+							socketRequests++
+							next.ServeHTTP(res, req)
+						})
+					},
+				}},
+			},
+		})
+	}()
+
+	waitFor(t, func() bool {
+		_, err := os.Stat(socket)
+		return err == nil
+	})
+
+	socketClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return new(net.Dialer).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	testCases := []struct {
+		name   string
+		client *http.Client
+		url    string
+		status int
+	}{
+		{"public route", testClient, "http://" + public.Addr().String() + "/api/v1/public", http.StatusOK},
+		{"public admin route", testClient, "http://" + public.Addr().String() + "/api/v1/admin/log-level", http.StatusNotFound},
+		{"public custom admin route", testClient, "http://" + public.Addr().String() + "/api/v1/admin/custom", http.StatusNotFound},
+		{"socket route", socketClient, "http://sentinel/api/v1/public", http.StatusOK},
+		{"socket admin route", socketClient, "http://sentinel/api/v1/admin/log-level", http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.client.Get(tc.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+
+			if res.StatusCode != tc.status {
+				t.Fatalf("expected status code to be %d, got %d", tc.status, res.StatusCode)
+			}
+		})
+	}
+
+	if socketRequests != 2 {
+		t.Fatalf("expected the socket middleware to run for 2 requests, got %d", socketRequests)
+	}
+
+	cancel()
+
+	if err := <-served; err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Fatalf("expected socket file to be removed, got %v", err)
+	}
+}
+
+func TestServeAdminListener(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	public, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saveTestToken(t, app, adminTestToken, 0, core.ScopeAdmin)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- Serve(ctx, app, ServeConfig{
+			Listener:  public,
+			Listeners: []ListenerConfig{{Listener: admin, Admin: true}},
+		})
+	}()
+
+	testCases := []struct {
+		name   string
+		url    string
+		token  string
+		status int
+	}{
+		{"admin route", "http://" + admin.Addr().String() + "/api/v1/admin/log-level", adminTestToken, http.StatusOK},
+		{"anonymous admin route", "http://" + admin.Addr().String() + "/api/v1/admin/log-level", "", http.StatusUnauthorized},
+		{"admin metrics", "http://" + admin.Addr().String() + "/metrics", "", http.StatusOK},
+		{"admin health", "http://" + admin.Addr().String() + "/api/v1/health", "", http.StatusOK},
+		{"public admin route", "http://" + public.Addr().String() + "/api/v1/admin/log-level", adminTestToken, http.StatusNotFound},
+		{"public metrics", "http://" + public.Addr().String() + "/metrics", "", http.StatusNotFound},
+		{"admin tokens", "http://" + admin.Addr().String() + "/api/v1/tokens", adminTestToken, http.StatusOK},
+		{"public tokens", "http://" + public.Addr().String() + "/api/v1/tokens", adminTestToken, http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, tc.url, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+
+			res, err := testClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+
+			if res.StatusCode != tc.status {
+				t.Fatalf("expected status code to be %d, got %d", tc.status, res.StatusCode)
+			}
+		})
+	}
+
+	cancel()
+
+	if err := <-served; err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+func TestServeOptions(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	var readHeaderTimeout time.Duration
+
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		readHeaderTimeout = e.Server.ReadHeaderTimeout
+		return e.Next()
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- Serve(ctx, app, ServeConfig{
+			Listener:           listener,
+			MaxHeaderBytes:     1 << 10,
+			H2C:                true,
+			KeepAlivesDisabled: true,
+			MaxConnections:     10,
+		})
+	}()
+
+	url := "http://" + listener.Addr().String() + "/api/v1/health"
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+
+	h2c := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+
+	res, err := h2c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	if res.ProtoMajor != 2 {
+		t.Fatalf("expected an HTTP/2 response, got %s", res.Proto)
+	}
+
+	res, err = new(http.Client).Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	if res.ProtoMajor != 1 || !res.Close {
+		t.Fatalf("expected an HTTP/1 response closing the connection, got %s (close %v)", res.Proto, res.Close)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Large", strings.Repeat("a", 8<<10))
+
+	res, err = testClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+
+	if res.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Fatalf("expected status code to be %d, got %d", http.StatusRequestHeaderFieldsTooLarge, res.StatusCode)
+	}
+
+	if readHeaderTimeout != DefaultServerReadHeaderTimeout {
+		t.Fatalf("expected read header timeout to be %s, got %s", DefaultServerReadHeaderTimeout, readHeaderTimeout)
+	}
+
+	cancel()
+
+	if err := <-served; err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+}
+
+// failingListener fails to accept connections.
+type failingListener struct {
+	net.Listener
+}
+
+func (l failingListener) Accept() (net.Conn, error) {
+	return nil, errors.New("accept failed")
+}
+
+func TestServeError(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	var drained, terminated bool
+
+	app.Go("job", func(ctx context.Context) error {
+		<-ctx.Done()
+		drained = true
+		return nil
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		terminated = true
+		return e.Next()
+	})
+
+	err = Serve(t.Context(), app, ServeConfig{Listener: failingListener{listener}, MetricsDisabled: true})
+	if err == nil || !strings.Contains(err.Error(), "accept failed") {
+		t.Fatalf("expected the accept error, got %v", err)
+	}
+
+	if !drained || !terminated {
+		t.Fatalf("expected the app to be drained and terminated, got drained %v and terminated %v", drained, terminated)
+	}
+}
+
+func TestServeListenError(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := Serve(t.Context(), app, ServeConfig{Port: port, MetricsDisabled: true}); err == nil {
+		t.Fatal("expected error for a port already in use")
+	}
+
+	supplied, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer supplied.Close()
+
+	err = Serve(t.Context(), app, ServeConfig{
+		Listener:        supplied,
+		MetricsDisabled: true,
+		Listeners:       []ListenerConfig{{Addr: listener.Addr().String(), Admin: true}},
+	})
+	if err == nil {
+		t.Fatal("expected error for a listener address already in use")
+	}
+
+	conn, err := net.Dial("tcp", supplied.Addr().String())
+	if err != nil {
+		t.Fatalf("expected the supplied listener to be left open, got %v", err)
+	}
+	_ = conn.Close()
+}
+
+func TestServeListenerTLSError(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	err = Serve(t.Context(), app, ServeConfig{
+		MetricsDisabled: true,
+		Listeners: []ListenerConfig{{
+			Addr:  "127.0.0.1:0",
+			Admin: true,
+			TLS:   TLSConfig{CertFile: "missing.pem", KeyFile: "missing-key.pem"},
+		}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid admin listener tls config") {
+		t.Fatalf("expected the admin listener tls error, got %v", err)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	var mu sync.Mutex
+	var steps []string
+
+	step := func(name string) {
+		mu.Lock()
+		steps = append(steps, name)
+		mu.Unlock()
+	}
+
+	started := make(chan struct{})
+
+	router := newRouter(app)
+	router.get("/slow", func(e *core.EventRequest) error {
+		close(started)
+		time.Sleep(time.Millisecond * 50)
+		step("request")
+		return e.Status(http.StatusOK)
+	})
+
+	app.Go("job", func(ctx context.Context) error {
+		<-ctx.Done()
+		step("job")
+		return nil
+	})
+
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		step("terminate")
+		return e.Next()
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: router.buildMux()}
+	go func() { _ = server.Serve(listener) }()
+
+	res := make(chan int, 1)
+
+	go func() {
+		r, err := testClient.Get("http://" + listener.Addr().String() + "/slow")
+		if err != nil {
+			res <- 0
+			return
+		}
+		_ = r.Body.Close()
+		res <- r.StatusCode
+	}()
+
+	<-started
+
+	err = shutdown(app, ServeConfig{
+		ShutdownGracePeriod: time.Second,
+		PreShutdownDelay:    time.Millisecond * 10,
+	}, server, nil)
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if status := <-res; status != http.StatusOK {
+		t.Fatalf("expected in-flight request to complete, got status %d", status)
+	}
+
+	if !app.HealthChecks().IsShuttingDown() {
+		t.Fatal("expected readiness to fail")
+	}
+
+	expected := "request,job,terminate"
+	if got := strings.Join(steps, ","); got != expected {
+		t.Fatalf("expected shutdown steps to be %q, got %q", expected, got)
+	}
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	router := newRouter(app)
+	router.get("/stuck", func(*core.EventRequest) error {
+		close(started)
+		<-release
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: router.buildMux()}
+	go func() { _ = server.Serve(listener) }()
+
+	go func() {
+		r, err := testClient.Get("http://" + listener.Addr().String() + "/stuck")
+		if err == nil {
+			_ = r.Body.Close()
+		}
+	}()
+
+	<-started
+
+	start := time.Now()
+
+	var terminateErr error
+	app.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		terminateErr = e.Context.Err()
+		return e.Next()
+	})
+
+	err = shutdown(app, ServeConfig{ShutdownGracePeriod: time.Millisecond * 20}, server)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("expected shutdown to stop after the grace period, took %s", elapsed)
+	}
+
+	if terminateErr != nil {
+		t.Fatalf("expected the terminate hooks to get a live context, got %v", terminateErr)
+	}
+}
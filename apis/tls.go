@@ -0,0 +1,110 @@
+package apis
+
+import (
+	"crypto/tls"
+	"errors"
+	"log/slog"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/certs"
+)
+
+// DefaultTLSMinVersion is the minimum TLS version used when none is set.
+const DefaultTLSMinVersion = "1.2"
+
+// TLSConfig defines the https options of the api server. TLS is enabled
+// when CertFile and KeyFile are set, setting only one of them is an error.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM encoded files reloaded when they change.
+	CertFile string
+	KeyFile  string
+
+	// MinVersion is the minimum TLS version, `1.2` (default) or `1.3`.
+	MinVersion string
+
+	// CipherSuites restricts the TLS 1.2 cipher suites, e.g.
+	// `TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`, which HTTP/2 requires when
+	// set. The TLS 1.3 cipher suites are not configurable.
+	CipherSuites []string
+
+	// ClientCAFile is a PEM bundle of the CAs verifying the client
+	// certificates. Clients that present a certificate are verified
+	// against it, and rejected without one if RequireClientCert is set.
+	ClientCAFile      string
+	RequireClientCert bool
+}
+
+// Enabled reports whether the server is served over TLS.
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// newTLSConfig creates the server tls.Config. The certificates and the
+// client CA bundle are reloaded on rotation, failures are logged and the
+// previous files keep being served.
+func newTLSConfig(app core.App, config TLSConfig) (*tls.Config, error) {
+	if config.CertFile == "" || config.KeyFile == "" {
+		return nil, errors.New("tls requires both a certificate and a key file")
+	}
+
+	if config.RequireClientCert && config.ClientCAFile == "" {
+		return nil, errors.New("tls client certificates require a client ca file")
+	}
+
+	if config.MinVersion == "" {
+		config.MinVersion = DefaultTLSMinVersion
+	}
+
+	minVersion, err := certs.ParseVersion(config.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherSuites, err := certs.ParseCipherSuites(config.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	// h2 is advertised below.
+	if err := certs.CheckHTTP2CipherSuites(minVersion, cipherSuites); err != nil {
+		return nil, err
+	}
+
+	reloader, err := certs.NewReloader(config.CertFile, config.KeyFile, config.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	reloader.OnError = func(err error) {
+		app.Logger().Error("failed to reload tls certificates", slog.String("error", err.Error()))
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if len(cipherSuites) > 0 {
+		tlsConfig.CipherSuites = cipherSuites
+	}
+
+	if config.ClientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if config.RequireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	// The client CA bundle can only be swapped per connection.
+	tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		clientConfig := tlsConfig.Clone()
+		clientConfig.GetConfigForClient = nil
+		clientConfig.ClientCAs = reloader.ClientCAs()
+		return clientConfig, nil
+	}
+
+	return tlsConfig, nil
+}
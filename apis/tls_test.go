@@ -0,0 +1,189 @@
+package apis
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tests"
+)
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	dir := t.TempDir()
+	ca := tests.NewTestCert(t, &x509.Certificate{IsCA: true, BasicConstraintsValid: true}, nil)
+	certFile, keyFile := ca.WriteFiles(t, dir, "ca")
+
+	testCases := []struct {
+		name   string
+		config TLSConfig
+	}{
+		{"missing key", TLSConfig{CertFile: certFile}},
+		{"missing files", TLSConfig{CertFile: filepath.Join(dir, "missing.pem"), KeyFile: keyFile}},
+		{"min version", TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "2.0"}},
+		{"cipher suites", TLSConfig{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"unknown"}}},
+		{"http2 cipher suite", TLSConfig{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}}},
+		{"insecure min version", TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.0"}},
+		{"required client cert", TLSConfig{CertFile: certFile, KeyFile: keyFile, RequireClientCert: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := newTLSConfig(app, tc.config); err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestServeMutualTLS(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	dir := t.TempDir()
+
+	ca := tests.NewTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+
+	server := tests.NewTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "sentinel"},
+		IPAddresses: []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+
+	agentURI, _ := url.Parse("spiffe://sentinel/cluster/eu-1")
+	agent := tests.NewTestCert(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "agent-eu-1", Organization: []string{"clusters"}},
+		URIs:        []*url.URL{agentURI},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	caFile, _ := ca.WriteFiles(t, dir, "ca")
+	certFile, keyFile := server.WriteFiles(t, dir, "server")
+
+	tlsConfig, err := newTLSConfig(app, TLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		MinVersion:   "1.3",
+		ClientCAFile: caFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := newRouter(app)
+	router.get("/identity", func(e *core.EventRequest) error {
+		return e.Json(map[string]any{"identity": e.ClientCert()}, http.StatusOK)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpServer := &http.Server{
+		Handler:   router.buildMux(),
+		TLSConfig: tlsConfig,
+		ErrorLog:  log.New(io.Discard, "", 0),
+	}
+	go func() { _ = httpServer.ServeTLS(listener, "", "") }()
+	defer httpServer.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Cert)
+
+	testCases := []struct {
+		name            string
+		clientConfig    *tls.Config
+		expectedContent []string
+	}{
+		{
+			name:            "no client certificate",
+			clientConfig:    &tls.Config{RootCAs: pool},
+			expectedContent: []string{`"identity":null`},
+		},
+		{
+			name:         "verified client certificate",
+			clientConfig: &tls.Config{RootCAs: pool, Certificates: []tls.Certificate{agent.Pair}},
+			expectedContent: []string{
+				`"common_name":"agent-eu-1"`,
+				`"organization":["clusters"]`,
+				`"uris":["spiffe://sentinel/cluster/eu-1"]`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tc.clientConfig}}
+
+			res, err := client.Get("https://" + listener.Addr().String() + "/identity")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+
+			body, _ := io.ReadAll(res.Body)
+
+			for _, content := range tc.expectedContent {
+				if !strings.Contains(string(body), content) {
+					t.Errorf("expected content %v in response body \n%v", content, string(body))
+				}
+			}
+		})
+	}
+
+	t.Run("untrusted client certificate", func(t *testing.T) {
+		other := tests.NewTestCert(t, &x509.Certificate{
+			Subject:     pkix.Name{CommonName: "intruder"},
+			ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		}, nil)
+
+		// Always sends the certificate, even if the server does not list
+		// its issuer among the accepted CAs.
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs: pool,
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return &other.Pair, nil
+			},
+		}}}
+
+		res, err := client.Get("https://" + listener.Addr().String() + "/identity")
+		if err == nil {
+			res.Body.Close()
+			t.Fatal("expected the handshake to fail")
+		}
+	})
+
+	t.Run("min version", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:    pool,
+			MaxVersion: tls.VersionTLS12,
+		}}}
+
+		res, err := client.Get("https://" + listener.Addr().String() + "/identity")
+		if err == nil {
+			res.Body.Close()
+			t.Fatal("expected tls 1.2 to be rejected")
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package apis
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/event"
+)
+
+// bindTokensApi registers the api tokens endpoints of the admin listeners,
+// requiring a token with the admin scope. The first token is created out
+// of band, see core.BaseAppConfig.AdminTokenHash.
+func bindTokensApi(r *router) {
+	r.get("/api/v1/tokens", RequireScope(core.ScopeAdmin, listTokens))
+	r.post("/api/v1/tokens", RequireScope(core.ScopeAdmin, createToken))
+	r.delete("/api/v1/tokens/{id}", RequireScope(core.ScopeAdmin, revokeToken))
+}
+
+// RequireScope wraps a route handler to only serve the requests
+// authenticated with a token granting the scope, see core.TokenScopes.
+func RequireScope(scope string, handler func(*core.EventRequest) error) func(*core.EventRequest) error {
+	return func(e *core.EventRequest) error {
+		token := e.Auth()
+		if token == nil {
+			return e.UnauthorizedError("The request requires an api token.")
+		}
+
+		if !token.HasScope(scope) {
+			return e.ForbiddenError("The token is missing the " + scope + " scope.")
+		}
+
+		return handler(e)
+	}
+}
+
+type tokenRequest struct {
+	Name   string   `json:"name"`
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+
+	// TTL is a Go duration after which the token expires, e.g. `720h`.
+	// The token never expires when empty.
+	TTL string `json:"ttl"`
+}
+
+// tokenResponse returns the raw token, only once at its creation.
+type tokenResponse struct {
+	*core.Token
+	Raw string `json:"token"`
+}
+
+func listTokens(e *core.EventRequest) error {
+	tokens, err := e.App.TokenStore().FindTokens(e.Request.Context())
+	if err != nil {
+		return err
+	}
+
+	if tokens == nil {
+		tokens = []*core.Token{}
+	}
+
+	resp := struct {
+		Tokens []*core.Token `json:"tokens"`
+	}{
+		Tokens: tokens,
+	}
+
+	return e.Json(resp, http.StatusOK)
+}
+
+// createToken creates a token owned by Owner, or by the owner of the
+// token authenticating the request when empty.
+func createToken(e *core.EventRequest) error {
+	var data tokenRequest
+
+	if err := e.BindJson(&data); err != nil {
+		return err
+	}
+
+	data.Name = strings.TrimSpace(data.Name)
+	data.Owner = strings.TrimSpace(data.Owner)
+
+	if data.Owner == "" && e.Auth() != nil {
+		data.Owner = e.Auth().Owner
+	}
+
+	details := make(map[string]event.FieldError)
+
+	if data.Name == "" {
+		details["name"] = event.NewFieldError("required", "cannot be blank")
+	}
+
+	if data.Owner == "" {
+		details["owner"] = event.NewFieldError("required", "cannot be blank")
+	}
+
+	if len(data.Scopes) == 0 {
+		details["scopes"] = event.NewFieldError("required", "cannot be blank")
+	} else if slices.ContainsFunc(data.Scopes, func(s string) bool { return !slices.Contains(core.TokenScopes, s) }) {
+		details["scopes"] = event.NewFieldError("invalid", "must be any of "+strings.Join(core.TokenScopes, ", "))
+	}
+
+	var ttl time.Duration
+
+	if data.TTL != "" {
+		d, err := time.ParseDuration(data.TTL)
+		if err != nil || d <= 0 {
+			details["ttl"] = event.NewFieldError("invalid", "must be a positive duration, e.g. 720h")
+		}
+		ttl = d
+	}
+
+	if len(details) > 0 {
+		return e.ValidationError("", details)
+	}
+
+	slices.Sort(data.Scopes)
+
+	token, raw := core.NewToken(data.Name, data.Owner, slices.Compact(data.Scopes), ttl)
+
+	if err := e.App.TokenStore().SaveToken(e.Request.Context(), token); err != nil {
+		return err
+	}
+
+	e.Logger().Info("api token created",
+		slog.String("token_id", token.ID),
+		slog.String("token_name", token.Name),
+		slog.String("owner", token.Owner),
+		slog.Any("scopes", token.Scopes),
+	)
+
+	return e.Json(tokenResponse{Token: token, Raw: raw}, http.StatusCreated)
+}
+
+// revokeToken deletes a token, which is rejected from then on.
+func revokeToken(e *core.EventRequest) error {
+	id := e.Request.PathValue("id")
+
+	if err := e.App.TokenStore().DeleteToken(e.Request.Context(), id); err != nil {
+		return err
+	}
+
+	e.Logger().Info("api token revoked", slog.String("token_id", id))
+
+	resp := struct {
+		ID string `json:"id"`
+	}{
+		ID: id,
+	}
+
+	return e.Json(resp, http.StatusOK)
+}
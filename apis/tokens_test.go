@@ -0,0 +1,347 @@
+package apis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tests"
+)
+
+// saveTestToken stores a token with a known raw value, already expired
+// when ttl is negative.
+func saveTestToken(t *testing.T, app *tests.TestApp, raw string, ttl time.Duration, scopes ...string) *core.Token {
+	t.Helper()
+
+	token, _ := core.NewToken(raw, "team-a", scopes, ttl)
+	token.ID = raw
+	token.Hash = core.HashToken(raw)
+
+	if ttl < 0 {
+		token.ExpiresAt = token.Created.Add(ttl)
+	}
+
+	if err := app.TokenStore().SaveToken(context.Background(), token); err != nil {
+		t.Fatal(err)
+	}
+
+	return token
+}
+
+func withTestTokens(t *testing.T, app *tests.TestApp) {
+	saveTestToken(t, app, "snt_admin", 0, core.ScopeAdmin)
+	saveTestToken(t, app, "snt_reader", 0, core.ScopeRulesRead)
+	saveTestToken(t, app, "snt_expired", -time.Hour, core.ScopeAdmin)
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "anonymous request",
+			url:            "/api/v1/health",
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"message":"API is healthy."`,
+			},
+		},
+		{
+			name:           "not a bearer token",
+			url:            "/api/v1/health",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Basic dXNlcjpwYXNz"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+				`"message":"The authorization header must be a bearer token."`,
+			},
+		},
+		{
+			name:           "unknown token",
+			url:            "/api/v1/health",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_unknown"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+			},
+		},
+		{
+			name:           "expired token",
+			url:            "/api/v1/health",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_expired"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+			},
+		},
+		{
+			name:           "valid token",
+			url:            "/api/v1/health",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "bearer snt_reader"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`"message":"API is healthy."`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestAuthenticateIdentity(t *testing.T) {
+	app, err := tests.NewTestApp()
+	if err != nil {
+		t.Fatalf("failed to initialize test app instance - %v", err)
+	}
+
+	saveTestToken(t, app, "snt_reader", 0, core.ScopeRulesRead)
+
+	var auth *core.Token
+
+	router := newRouter(app)
+	router.Use(authenticate(app))
+	router.get("/rules", RequireScope(core.ScopeRulesRead, func(e *core.EventRequest) error {
+		auth = e.Auth()
+		return e.Json(map[string]any{}, http.StatusOK)
+	}))
+	router.put("/rules", RequireScope(core.ScopeRulesWrite, func(e *core.EventRequest) error {
+		return e.Json(map[string]any{}, http.StatusOK)
+	}))
+
+	mux := router.buildMux()
+
+	testCases := []struct {
+		method         string
+		token          string
+		expectedStatus int
+	}{
+		{http.MethodGet, "", http.StatusUnauthorized},
+		{http.MethodGet, "snt_reader", http.StatusOK},
+		{http.MethodPut, "snt_reader", http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		req, _ := http.NewRequest(tc.method, "/rules", nil)
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != tc.expectedStatus {
+			t.Fatalf("expected %s /rules with token %q to be %d, got %d", tc.method, tc.token, tc.expectedStatus, rec.Code)
+		}
+	}
+
+	if auth == nil || auth.ID != "snt_reader" || auth.Owner != "team-a" {
+		t.Fatalf("expected the request identity to be the reader token, got %+v", auth)
+	}
+
+	stored, err := app.TokenStore().FindTokenByHash(context.Background(), core.HashToken("snt_reader"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored.LastUsedAt.IsZero() {
+		t.Fatal("expected the token last used time to be updated")
+	}
+}
+
+func TestListTokens(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "public listener",
+			url:            "/api/v1/tokens",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_admin"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 404,
+			expectedContent: []string{
+				"404 page not found",
+			},
+		},
+		{
+			name:           "missing admin scope",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_reader"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 403,
+			expectedContent: []string{
+				`"code":"FORBIDDEN"`,
+				`"message":"The token is missing the admin scope."`,
+			},
+		},
+		{
+			name:           "admin token",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer snt_admin"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"tokens":[{"id":"snt_expired"`,
+				`{"id":"snt_reader","name":"snt_reader","owner":"team-a","scopes":["rules:read"],"created":"`,
+			},
+		},
+		{
+			name:           "admin listener",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodGet,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"tokens":[{"id":"snt_admin_listener"`,
+			},
+		},
+		{
+			name:           "anonymous admin listener request",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": ""},
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestCreateToken(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "admin listener",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodPost,
+			body:           strings.NewReader(`{"name":"ci","owner":"team-b","scopes":["rules:write","rules:read","rules:read"],"ttl":"720h"}`),
+			expectedStatus: 201,
+			expectedContent: []string{
+				`"name":"ci","owner":"team-b","scopes":["rules:read","rules:write"]`,
+				`"expires_at":"`,
+				`"token":"snt_`,
+			},
+		},
+		{
+			name:           "owner of the admin token",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodPost,
+			headers:        map[string]string{"Authorization": "Bearer snt_admin"},
+			body:           strings.NewReader(`{"name":"agent","scopes":["clusters:sync"]}`),
+			beforeTest:     withTestTokens,
+			expectedStatus: 201,
+			expectedContent: []string{
+				`"name":"agent","owner":"team-a","scopes":["clusters:sync"]`,
+				`"token":"snt_`,
+			},
+		},
+		{
+			name:           "missing admin scope",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodPost,
+			headers:        map[string]string{"Authorization": "Bearer snt_reader"},
+			body:           strings.NewReader(`{"name":"agent","scopes":["admin"]}`),
+			beforeTest:     withTestTokens,
+			expectedStatus: 403,
+			expectedContent: []string{
+				`"code":"FORBIDDEN"`,
+			},
+		},
+		{
+			name:           "invalid values",
+			url:            "/api/v1/tokens",
+			admin:          true,
+			method:         http.MethodPost,
+			body:           strings.NewReader(`{"name":" ","scopes":["rules:delete"],"ttl":"-1h"}`),
+			expectedStatus: 422,
+			expectedContent: []string{
+				`"code":"VALIDATION_FAILED"`,
+				`"name":{"code":"required"`,
+				`"scopes":{"code":"invalid"`,
+				`"ttl":{"code":"invalid"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	t.Parallel()
+
+	scenarios := []apiTestScenario{
+		{
+			name:           "revoke token",
+			url:            "/api/v1/tokens/snt_reader",
+			admin:          true,
+			method:         http.MethodDelete,
+			headers:        map[string]string{"Authorization": "Bearer snt_admin"},
+			beforeTest:     withTestTokens,
+			expectedStatus: 200,
+			expectedContent: []string{
+				`{"id":"snt_reader"}`,
+			},
+		},
+		{
+			name:           "missing token",
+			url:            "/api/v1/tokens/snt_missing",
+			admin:          true,
+			method:         http.MethodDelete,
+			expectedStatus: 404,
+			expectedContent: []string{
+				`"code":"NOT_FOUND"`,
+			},
+		},
+		{
+			name:    "revoked token is rejected",
+			url:     "/api/v1/health",
+			method:  http.MethodGet,
+			headers: map[string]string{"Authorization": "Bearer snt_reader"},
+			beforeTest: func(t *testing.T, app *tests.TestApp) {
+				withTestTokens(t, app)
+				if err := app.TokenStore().DeleteToken(context.Background(), "snt_reader"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedStatus: 401,
+			expectedContent: []string{
+				`"code":"UNAUTHORIZED"`,
+			},
+		},
+	}
+
+	for _, s := range scenarios {
+		s.Test(t)
+	}
+}
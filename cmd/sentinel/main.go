@@ -1,17 +1,62 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dlbarduzzi/sentinel"
+	"github.com/dlbarduzzi/sentinel/core"
 )
 
 func main() {
 	app := sentinel.New()
 
-	if err := app.Start(); err != nil {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The signals are only handled while the server runs, the other
+	// commands keep the default behavior of exiting on the first signal.
+	app.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		defer signal.Stop(signals)
+
+		go handleSignals(app, signals, cancel)
+
+		return e.Next()
+	})
+
+	if err := app.ExecuteContext(ctx); err != nil {
 		fmt.Fprintf(os.Stderr, "[error] %s\n", err)
 		os.Exit(1)
 	}
 }
+
+// handleSignals reloads the config on SIGHUP and cancels the server
+// context on SIGINT and SIGTERM.
+func handleSignals(app *sentinel.Sentinel, signals <-chan os.Signal, cancel context.CancelFunc) {
+	shuttingDown := false
+
+	for sig := range signals {
+		if sig == syscall.SIGHUP {
+			if err := app.ReloadConfig(); err != nil {
+				app.Logger().Error("failed to reload config", slog.String("error", err.Error()))
+			}
+			continue
+		}
+
+		// A second signal skips the graceful shutdown.
+		if shuttingDown {
+			app.Logger().Warn("received second shutdown signal, forcing exit", slog.String("signal", sig.String()))
+			os.Exit(1)
+		}
+
+		app.Logger().Info("received shutdown signal", slog.String("signal", sig.String()))
+		shuttingDown = true
+		cancel()
+	}
+}
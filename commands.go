@@ -0,0 +1,242 @@
+package sentinel
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/rules"
+	"github.com/spf13/cobra"
+)
+
+func (s *Sentinel) registerDefaultCommands() {
+	s.RootCmd.AddCommand(
+		s.newServeCommand(),
+		s.newConfigCommand(),
+		s.newRulesCommand(),
+		s.newMigrateCommand(),
+		s.newTokensCommand(),
+		s.newVersionCommand(),
+	)
+}
+
+func (s *Sentinel) newServeCommand() *cobra.Command {
+	var port int
+	var logLevel string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Starts the api server",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if cmd.Flags().Changed("port") {
+				s.flags["SERVER_PORT"] = strconv.Itoa(port)
+			}
+
+			if cmd.Flags().Changed("log-level") {
+				s.flags["LOG_LEVEL"] = logLevel
+			}
+
+			return s.Start(cmd.Context())
+		},
+	}
+
+	cmd.Flags().IntVar(&port, "port", s.defaults.ServerPort, "the api server port (overrides SENTINEL_SERVER_PORT)")
+	cmd.Flags().StringVar(&logLevel, "log-level", s.defaults.LogLevel, "the log level (overrides SENTINEL_LOG_LEVEL)")
+
+	return cmd
+}
+
+func (s *Sentinel) newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspects the Sentinel configuration",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "print",
+		Short: "Prints the effective configuration and where each setting came from",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := s.loadConfig(); err != nil {
+				return err
+			}
+
+			for _, field := range configFields {
+				cmd.Printf("%s=%s # %s\n", field.key, field.get(&s.config), s.configSources[field.key])
+			}
+
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+func (s *Sentinel) newRulesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rules",
+		Short: "Manages Prometheus rule files",
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "validate <files...>",
+		Short: "Validates the structure of Prometheus rule files",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var failed int
+
+			for _, path := range args {
+				file, err := rules.ParseFile(path)
+				if err == nil {
+					err = file.Validate()
+				}
+
+				if err != nil {
+					failed++
+					cmd.PrintErrf("%s: invalid\n%v\n", path, err)
+					continue
+				}
+
+				cmd.Printf("%s: valid\n", path)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d rule files are invalid", failed, len(args))
+			}
+
+			return nil
+		},
+	})
+
+	return cmd
+}
+
+// errNoStore is returned by the migrate commands, Sentinel has no store
+// with migrations to run yet.
+var errNoStore = errors.New("no store configured, there are no migrations to run")
+
+func (s *Sentinel) newMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Runs the store migrations",
+	}
+
+	subcommands := []struct {
+		use   string
+		short string
+	}{
+		{"up", "Applies the pending migrations"},
+		{"down", "Reverts the last applied migration"},
+		{"status", "Prints the applied and pending migrations"},
+	}
+
+	for _, sub := range subcommands {
+		cmd.AddCommand(&cobra.Command{
+			Use:   sub.use,
+			Short: sub.short,
+			Args:  cobra.NoArgs,
+			RunE: func(*cobra.Command, []string) error {
+				return errNoStore
+			},
+		})
+	}
+
+	return cmd
+}
+
+// errNoTokensStore is returned by the tokens commands when the tokens are
+// kept in the memory of the server.
+var errNoTokensStore = errors.New("no tokens store configured, set TOKENS_FILE to manage the tokens from the command line")
+
+func (s *Sentinel) newTokensCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tokens",
+		Short: "Manages the api tokens",
+	}
+
+	var name, owner, ttl string
+	var scopes []string
+
+	create := &cobra.Command{
+		Use:   "create",
+		Short: "Creates an api token, e.g. the first admin token, and prints it once",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if err := s.loadConfig(); err != nil {
+				return err
+			}
+
+			store, err := s.config.tokensStore()
+			if err != nil {
+				return err
+			}
+
+			if store == nil {
+				return errNoTokensStore
+			}
+
+			name, owner = strings.TrimSpace(name), strings.TrimSpace(owner)
+
+			if name == "" || owner == "" {
+				return errors.New("the token name and owner cannot be blank")
+			}
+
+			for _, scope := range scopes {
+				if !slices.Contains(core.TokenScopes, scope) {
+					return fmt.Errorf("invalid scope %q, must be any of %s", scope, strings.Join(core.TokenScopes, ", "))
+				}
+			}
+
+			var d time.Duration
+
+			if ttl != "" {
+				d, err = time.ParseDuration(ttl)
+				if err != nil || d <= 0 {
+					return fmt.Errorf("invalid ttl %q, must be a positive duration, e.g. 720h", ttl)
+				}
+			}
+
+			slices.Sort(scopes)
+
+			token, raw := core.NewToken(name, owner, slices.Compact(scopes), d)
+
+			if err := store.SaveToken(cmd.Context(), token); err != nil {
+				return fmt.Errorf("failed to save token - %w", err)
+			}
+
+			cmd.PrintErrf("token %s created, it is only printed once\n", token.ID)
+			cmd.Println(raw)
+
+			return nil
+		},
+	}
+
+	create.Flags().StringVar(&name, "name", "", "the token name")
+	create.Flags().StringVar(&owner, "owner", "", "the token owner")
+	create.Flags().StringSliceVar(&scopes, "scope", nil, "the token scopes, any of "+strings.Join(core.TokenScopes, ", "))
+	create.Flags().StringVar(&ttl, "ttl", "", "the duration after which the token expires, e.g. 720h (never when empty)")
+
+	for _, flag := range []string{"name", "owner", "scope"} {
+		_ = create.MarkFlagRequired(flag)
+	}
+
+	cmd.AddCommand(create)
+
+	return cmd
+}
+
+func (s *Sentinel) newVersionCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "version",
+		Short: "Prints the Sentinel version",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			cmd.Printf("sentinel version %s\n", Version)
+		},
+	}
+}
@@ -0,0 +1,172 @@
+package sentinel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/core"
+)
+
+func executeCommand(t *testing.T, app *Sentinel, args ...string) (string, error) {
+	t.Helper()
+
+	buf := new(bytes.Buffer)
+
+	app.RootCmd.SetOut(buf)
+	app.RootCmd.SetErr(buf)
+	app.RootCmd.SetArgs(args)
+
+	err := app.Execute()
+
+	return buf.String(), err
+}
+
+func TestVersionCommand(t *testing.T) {
+	app := NewWithConfig(Config{})
+
+	out, err := executeCommand(t, app, "version")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := "sentinel version " + Version; !strings.Contains(out, expected) {
+		t.Fatalf("expected output to contain %q, got %q", expected, out)
+	}
+}
+
+func TestConfigPrintCommand(t *testing.T) {
+	config := DefaultConfig()
+	config.LogLevel = "debug"
+	config.ServerPort = 9000
+
+	app := NewWithConfig(config)
+
+	out, err := executeCommand(t, app, "config", "print")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"LOG_LEVEL=debug # default\n",
+		"SERVER_PORT=9000 # default\n",
+	}
+
+	for _, expected := range expected {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got \n%v", expected, out)
+		}
+	}
+}
+
+func TestConfigPrintCommandPartialConfig(t *testing.T) {
+	app := NewWithConfig(Config{ServerPort: 9000})
+
+	out, err := executeCommand(t, app, "config", "print")
+	if err != nil {
+		t.Fatalf("expected the zero settings to take their default, got %v", err)
+	}
+
+	expected := []string{
+		"SERVER_PORT=9000 # default\n",
+		"LOG_LEVEL=info # default\n",
+		"SERVER_READ_TIMEOUT=5s # default\n",
+		"TRACING_EXPORTER=none # default\n",
+	}
+
+	for _, expected := range expected {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got \n%v", expected, out)
+		}
+	}
+}
+
+func TestRulesValidateCommand(t *testing.T) {
+	dir := t.TempDir()
+
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+
+	files := map[string]string{
+		valid:   "groups:\n  - name: example\n    rules:\n      - alert: Down\n        expr: up == 0\n",
+		invalid: "groups:\n  - name: example\n    rules:\n      - alert: Down\n",
+	}
+
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := executeCommand(t, NewWithConfig(Config{}), "rules", "validate", valid)
+	if err != nil {
+		t.Fatalf("expected valid rule file, got %v \n%v", err, out)
+	}
+
+	out, err = executeCommand(t, NewWithConfig(Config{}), "rules", "validate", valid, invalid)
+	if err == nil {
+		t.Fatal("expected invalid rule file error, got nil")
+	}
+
+	for _, expected := range []string{valid + ": valid", invalid + ": invalid", "expr is required"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got \n%v", expected, out)
+		}
+	}
+}
+
+func TestMigrateCommands(t *testing.T) {
+	for _, sub := range []string{"up", "down", "status"} {
+		t.Run(sub, func(t *testing.T) {
+			_, err := executeCommand(t, NewWithConfig(Config{}), "migrate", sub)
+			if !errors.Is(err, errNoStore) {
+				t.Fatalf("expected migrate %s to fail with %q, got %v", sub, errNoStore, err)
+			}
+		})
+	}
+}
+
+func TestTokensCreateCommand(t *testing.T) {
+	args := []string{"tokens", "create", "--name", "first", "--owner", "platform", "--scope", "admin"}
+
+	if _, err := executeCommand(t, NewWithConfig(DefaultConfig()), args...); !errors.Is(err, errNoTokensStore) {
+		t.Fatalf("expected error %v, got %v", errNoTokensStore, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	t.Setenv("SENTINEL_TOKENS_FILE", path)
+
+	if _, err := executeCommand(t, NewWithConfig(DefaultConfig()), "tokens", "create", "--name", "ci", "--owner", "platform", "--scope", "rules:delete"); err == nil {
+		t.Fatal("expected an invalid scope error")
+	}
+
+	out, err := executeCommand(t, NewWithConfig(DefaultConfig()), args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	raw := lines[len(lines)-1]
+
+	if !strings.HasPrefix(raw, core.TokenPrefix) {
+		t.Fatalf("expected the raw token to be printed, got %q", out)
+	}
+
+	store, err := core.NewFileTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.FindTokenByHash(context.Background(), core.HashToken(raw))
+	if err != nil {
+		t.Fatalf("expected the token to be saved in the tokens file, got %v", err)
+	}
+
+	if token.Name != "first" || token.Owner != "platform" || !token.HasScope(core.ScopeAdmin) {
+		t.Fatalf("expected the admin token of platform, got %+v", token)
+	}
+}
@@ -0,0 +1,662 @@
+package sentinel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/apis"
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/certs"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"github.com/dlbarduzzi/sentinel/tools/registry"
+	"github.com/dlbarduzzi/sentinel/tools/tracing"
+)
+
+const (
+	// envPrefix prefixes every Sentinel env variable.
+	envPrefix = "SENTINEL"
+
+	// envConfigFile is the env variable read when no --config flag is set.
+	envConfigFile = "SENTINEL_CONFIG_FILE"
+)
+
+// Config is the Sentinel initialization config struct. The values passed
+// to NewWithConfig are the defaults, overridden by the config file, .env
+// file, env variables and command flags, in this order. Its zero settings
+// take their DefaultConfig value.
+type Config struct {
+	// Logger configs. LogOutput is stderr, stdout or a file path, rotated
+	// with the LogFile settings.
+	LogLevel          string
+	LogFormat         string
+	LogOutput         string
+	LogFileMaxSizeMB  int
+	LogFileMaxAgeDays int
+	LogFileMaxBackups int
+	LogFileCompress   bool
+
+	// LogRedactKeys is a comma separated list of attribute keys masked
+	// in the logs, on top of logging.DefaultRedactKeys.
+	LogRedactKeys string
+
+	// LogSinks replaces LogOutput to write the logs to several outputs,
+	// each with its own level and format. It can only be set in code.
+	LogSinks []logging.SinkConfig
+
+	// Logs persistence configs, see core.BaseAppConfig.LogsPersist.
+	// LogsStore can only be set in code.
+	LogsPersist bool
+	LogsLevel   string
+	LogsMaxDays int
+	LogsStore   core.LogStore
+
+	// TokensStore persists the api tokens, see core.BaseAppConfig.TokensStore.
+	// It can only be set in code, and TokensFile is used otherwise. The
+	// tokens are kept in memory when both are empty.
+	TokensStore core.TokenStore
+	TokensFile  string
+
+	// AdminTokenHash is the hex encoded SHA-256 of a raw token granted
+	// the admin scope, see core.BaseAppConfig.AdminTokenHash.
+	AdminTokenHash string
+
+	// Server configs. The timeouts are set as `30s` or a bare number of
+	// seconds in the config file, .env and environment variables.
+	//
+	// The timeouts used to be a number of seconds. The values below 1ms
+	// passed to NewWithConfig, e.g. `ServerReadTimeout: 5`, are still read
+	// as seconds with a deprecation warning, use `5 * time.Second` instead.
+	ServerPort         int
+	ServerIdleTimeout  time.Duration
+	ServerReadTimeout  time.Duration
+	ServerWriteTimeout time.Duration
+
+	// ServerReadHeaderTimeout bounds the time to read the request headers
+	// and ServerMaxHeaderBytes their size, 0 keeps the Go default of 1MB.
+	ServerReadHeaderTimeout time.Duration
+	ServerMaxHeaderBytes    int
+
+	// ServerH2C serves HTTP/2 without TLS next to HTTP/1, e.g. behind a
+	// service mesh. ServerKeepAlivesDisabled closes the connections after
+	// every request.
+	ServerH2C                bool
+	ServerKeepAlivesDisabled bool
+
+	// ServerMaxConnections limits the connections open at once on the
+	// public listeners, 0 means unlimited.
+	ServerMaxConnections int
+
+	// ServerSocket is an optional Unix socket path serving the public
+	// api to local agents, next to ServerPort.
+	ServerSocket string
+
+	// AdminAddr serves the admin endpoints and the metrics, e.g.
+	// `127.0.0.1:9090` or `unix:/run/sentinel/admin.sock`. They are never
+	// served on ServerPort, and the admin endpoints are disabled when empty.
+	AdminAddr string
+
+	// ServerShutdownGracePeriod bounds the graceful shutdown and
+	// ServerPreShutdownDelay delays it while the readiness probe fails.
+	// ServerTerminateTimeout bounds the OnTerminate hooks that follow.
+	ServerShutdownGracePeriod time.Duration
+	ServerPreShutdownDelay    time.Duration
+	ServerTerminateTimeout    time.Duration
+
+	// Access log configs.
+	AccessLogDisabled   bool
+	AccessLogSkipHealth bool
+	AccessLogSampleRate float64
+
+	// Metrics configs.
+	MetricsDisabled bool
+	MetricsPort     int
+
+	// Tracing configs. TracingEndpoint is the OTLP HTTP collector url,
+	// the standard OTEL_EXPORTER_OTLP_* env variables are used when empty.
+	TracingExporter    string
+	TracingEndpoint    string
+	TracingSampleRatio float64
+
+	// TLS configs. The api is served over https when TLSCertFile and
+	// TLSKeyFile are set, and TLSCipherSuites is a comma separated list.
+	// Clients presenting a certificate are verified against the
+	// TLSClientCAFile bundle.
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSMinVersion         string
+	TLSCipherSuites       string
+	TLSClientCAFile       string
+	TLSClientCertRequired bool
+}
+
+// fillDefaults sets the zero settings to their DefaultConfig value, so
+// the callers of NewWithConfig only set what they override. A setting is
+// set to its zero value with the config file, .env or env variables.
+func (c *Config) fillDefaults() {
+	defaults := DefaultConfig()
+
+	var zero Config
+
+	for _, field := range configFields {
+		if field.get(c) == field.get(&zero) {
+			// The default values are valid for their own field.
+			_ = field.set(c, field.get(&defaults))
+		}
+	}
+}
+
+// upgradeLegacyTimeouts converts the timeouts set as a number of seconds,
+// from before they were durations, and returns their keys.
+func (c *Config) upgradeLegacyTimeouts() []string {
+	timeouts := []struct {
+		key   string
+		value *time.Duration
+	}{
+		{"SERVER_IDLE_TIMEOUT", &c.ServerIdleTimeout},
+		{"SERVER_READ_TIMEOUT", &c.ServerReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", &c.ServerWriteTimeout},
+	}
+
+	var keys []string
+
+	for _, timeout := range timeouts {
+		if *timeout.value > 0 && *timeout.value < time.Millisecond {
+			*timeout.value *= time.Second
+			keys = append(keys, timeout.key)
+		}
+	}
+
+	return keys
+}
+
+// DefaultConfig returns the config used by New. Applications calling
+// NewWithConfig can start from it and override only what they need.
+func DefaultConfig() Config {
+	return Config{
+		LogLevel:           string(logging.DefaultLevel),
+		LogFormat:          string(logging.FormatJson),
+		LogOutput:          logging.OutputStderr,
+		LogFileMaxSizeMB:   100,
+		LogsPersist:        false,
+		LogsLevel:          string(logging.LevelInfo),
+		LogsMaxDays:        7,
+		ServerPort:         8090,
+		ServerIdleTimeout:  apis.DefaultServerIdleTimeout,
+		ServerReadTimeout:  apis.DefaultServerReadTimeout,
+		ServerWriteTimeout: apis.DefaultServerWriteTimeout,
+
+		ServerReadHeaderTimeout: apis.DefaultServerReadHeaderTimeout,
+		ServerMaxHeaderBytes:    0,
+		ServerMaxConnections:    0,
+
+		ServerShutdownGracePeriod: apis.DefaultShutdownGracePeriod,
+		ServerPreShutdownDelay:    0,
+		ServerTerminateTimeout:    apis.DefaultTerminateTimeout,
+
+		AdminAddr: "127.0.0.1:9090",
+
+		AccessLogDisabled:   false,
+		AccessLogSkipHealth: true,
+		AccessLogSampleRate: 1,
+
+		MetricsDisabled: false,
+		MetricsPort:     0,
+
+		TracingExporter:    tracing.ExporterNone,
+		TracingSampleRatio: 1,
+
+		TLSMinVersion: apis.DefaultTLSMinVersion,
+	}
+}
+
+// configError is a problem found in a single config setting.
+type configError struct {
+	key string
+	err error
+}
+
+// Error makes it compatible with the `error` interface.
+func (e configError) Error() string {
+	return e.key + ": " + e.err.Error()
+}
+
+// Validate checks every setting and returns an error listing all the
+// invalid ones, or nil if the config is valid.
+func (c Config) Validate() error {
+	problems := c.validate()
+
+	errs := make([]error, 0, len(problems))
+	for _, problem := range problems {
+		errs = append(errs, problem)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c Config) validate() []configError {
+	var problems []configError
+
+	add := func(key string, format string, args ...any) {
+		problems = append(problems, configError{key: key, err: fmt.Errorf(format, args...)})
+	}
+
+	if !logging.LogLevel(c.LogLevel).IsValid() {
+		add("LOG_LEVEL", "must be one of debug, info, warn or error, got %q", c.LogLevel)
+	}
+
+	if !logging.LogFormat(c.LogFormat).IsValid() {
+		add("LOG_FORMAT", "must be one of text or json, got %q", c.LogFormat)
+	}
+
+	if strings.TrimSpace(c.LogOutput) == "" {
+		add("LOG_OUTPUT", "must be stderr, stdout or a file path")
+	}
+
+	logFileLimits := []struct {
+		key   string
+		value int
+	}{
+		{"LOG_FILE_MAX_SIZE_MB", c.LogFileMaxSizeMB},
+		{"LOG_FILE_MAX_AGE_DAYS", c.LogFileMaxAgeDays},
+		{"LOG_FILE_MAX_BACKUPS", c.LogFileMaxBackups},
+	}
+
+	for _, limit := range logFileLimits {
+		if limit.value < 0 {
+			add(limit.key, "must not be negative, got %d", limit.value)
+		}
+	}
+
+	if !logging.LogLevel(c.LogsLevel).IsValid() {
+		add("LOGS_LEVEL", "must be one of debug, info, warn or error, got %q", c.LogsLevel)
+	}
+
+	if c.LogsMaxDays < 1 {
+		add("LOGS_MAX_DAYS", "must be greater than 0, got %d", c.LogsMaxDays)
+	}
+
+	if c.ServerPort < 1 || c.ServerPort > 65535 {
+		add("SERVER_PORT", "must be between 1 and 65535, got %d", c.ServerPort)
+	}
+
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"SERVER_IDLE_TIMEOUT", c.ServerIdleTimeout},
+		{"SERVER_READ_TIMEOUT", c.ServerReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", c.ServerWriteTimeout},
+		{"SERVER_READ_HEADER_TIMEOUT", c.ServerReadHeaderTimeout},
+		{"SERVER_SHUTDOWN_GRACE_PERIOD", c.ServerShutdownGracePeriod},
+		{"SERVER_TERMINATE_TIMEOUT", c.ServerTerminateTimeout},
+	}
+
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			add(timeout.key, "must be greater than 0, got %s", timeout.value)
+		}
+	}
+
+	if c.ServerMaxHeaderBytes < 0 {
+		add("SERVER_MAX_HEADER_BYTES", "must not be negative, got %d", c.ServerMaxHeaderBytes)
+	}
+
+	if c.ServerMaxConnections < 0 {
+		add("SERVER_MAX_CONNECTIONS", "must not be negative, got %d", c.ServerMaxConnections)
+	}
+
+	if c.AdminAddr != "" && !strings.HasPrefix(c.AdminAddr, "unix:") {
+		if _, port, err := net.SplitHostPort(c.AdminAddr); err != nil {
+			add("ADMIN_ADDR", "must be a host:port address or a unix: socket path, got %q", c.AdminAddr)
+		} else if port == strconv.Itoa(c.ServerPort) {
+			add("ADMIN_ADDR", "must use a port different from SERVER_PORT %d", c.ServerPort)
+		} else if !c.MetricsDisabled && c.MetricsPort > 0 && port == strconv.Itoa(c.MetricsPort) {
+			add("ADMIN_ADDR", "must use a port different from METRICS_PORT %d", c.MetricsPort)
+		}
+	}
+
+	if c.ServerPreShutdownDelay < 0 {
+		add("SERVER_PRE_SHUTDOWN_DELAY", "must not be negative, got %s", c.ServerPreShutdownDelay)
+	}
+
+	// A sample rate of 0 samples everything, like apis.AccessLogConfig.
+	if c.AccessLogSampleRate < 0 || c.AccessLogSampleRate > 1 {
+		add("ACCESS_LOG_SAMPLE_RATE", "must be between 0 (default of 1) and 1, got %v", c.AccessLogSampleRate)
+	}
+
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		add("METRICS_PORT", "must be between 0 and 65535, got %d", c.MetricsPort)
+	} else if !c.MetricsDisabled && c.MetricsPort > 0 && c.MetricsPort == c.ServerPort {
+		add("METRICS_PORT", "must be different from SERVER_PORT %d", c.ServerPort)
+	}
+
+	switch c.TracingExporter {
+	case tracing.ExporterNone, tracing.ExporterStdout, tracing.ExporterOTLPHTTP:
+	default:
+		add("TRACING_EXPORTER", "must be one of none, stdout or otlp-http, got %q", c.TracingExporter)
+	}
+
+	// A sample ratio of 0 samples everything, like tracing.Config.
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		add("TRACING_SAMPLE_RATIO", "must be between 0 (default of 1) and 1, got %v", c.TracingSampleRatio)
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		add("TLS_CERT_FILE", "must be set together with TLS_KEY_FILE")
+	}
+
+	if c.TLSCertFile != "" {
+		minVersion, err := certs.ParseVersion(c.TLSMinVersion)
+		if err != nil {
+			add("TLS_MIN_VERSION", "must be 1.2 or 1.3, got %q", c.TLSMinVersion)
+		}
+
+		suites, err := certs.ParseCipherSuites(c.tlsCipherSuites())
+		if err != nil {
+			add("TLS_CIPHER_SUITES", "%v", err)
+		} else if err := certs.CheckHTTP2CipherSuites(minVersion, suites); err != nil && minVersion != 0 {
+			add("TLS_CIPHER_SUITES", "%v", err)
+		}
+	} else if c.TLSClientCAFile != "" {
+		add("TLS_CLIENT_CA_FILE", "requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+
+	if c.TLSClientCertRequired && c.TLSClientCAFile == "" {
+		add("TLS_CLIENT_CERT_REQUIRED", "requires TLS_CLIENT_CA_FILE")
+	}
+
+	if c.AdminTokenHash != "" {
+		if hash, err := hex.DecodeString(c.AdminTokenHash); err != nil || len(hash) != sha256.Size {
+			add("ADMIN_TOKEN_HASH", "must be a hex encoded SHA-256 hash")
+		}
+	}
+
+	return problems
+}
+
+// logRedactConfig returns the default redaction extended with the
+// LogRedactKeys.
+func (c Config) logRedactConfig() *logging.RedactConfig {
+	config := logging.DefaultRedactConfig()
+	config.Keys = slices.Clone(config.Keys)
+
+	for key := range strings.SplitSeq(c.LogRedactKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			config.Keys = append(config.Keys, key)
+		}
+	}
+
+	return &config
+}
+
+// tokensStore returns the TokensStore set in code or the store of the
+// TokensFile, or nil when the tokens are kept in memory.
+func (c Config) tokensStore() (core.TokenStore, error) {
+	if c.TokensStore != nil {
+		return c.TokensStore, nil
+	}
+
+	if c.TokensFile == "" {
+		return nil, nil
+	}
+
+	store, err := core.NewFileTokenStore(c.TokensFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tokens file - %w", err)
+	}
+
+	return store, nil
+}
+
+// tlsCipherSuites returns the names listed in TLSCipherSuites.
+func (c Config) tlsCipherSuites() []string {
+	var names []string
+
+	for name := range strings.SplitSeq(c.TLSCipherSuites, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// configField maps a Config field to its registry key.
+type configField struct {
+	key string
+
+	// aliases are former keys still read for backward compatibility.
+	aliases []string
+
+	// reloadable settings are applied at runtime by Sentinel.ReloadConfig.
+	reloadable bool
+
+	get func(c *Config) string
+	set func(c *Config, value string) error
+}
+
+func stringField(key string, ptr func(c *Config) *string) configField {
+	return configField{
+		key: key,
+		get: func(c *Config) string { return *ptr(c) },
+		set: func(c *Config, value string) error {
+			*ptr(c) = value
+			return nil
+		},
+	}
+}
+
+func intField(key string, ptr func(c *Config) *int) configField {
+	return configField{
+		key: key,
+		get: func(c *Config) string { return strconv.Itoa(*ptr(c)) },
+		set: func(c *Config, value string) error {
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid integer %q", value)
+			}
+			*ptr(c) = v
+			return nil
+		},
+	}
+}
+
+func boolField(key string, ptr func(c *Config) *bool) configField {
+	return configField{
+		key: key,
+		get: func(c *Config) string { return strconv.FormatBool(*ptr(c)) },
+		set: func(c *Config, value string) error {
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q", value)
+			}
+			*ptr(c) = v
+			return nil
+		},
+	}
+}
+
+func floatField(key string, ptr func(c *Config) *float64) configField {
+	return configField{
+		key: key,
+		get: func(c *Config) string { return strconv.FormatFloat(*ptr(c), 'g', -1, 64) },
+		set: func(c *Config, value string) error {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			*ptr(c) = v
+			return nil
+		},
+	}
+}
+
+// durationField reads a Go duration string. Bare integers are read as
+// seconds to stay compatible with the former `_SECS` keys.
+func durationField(key string, ptr func(c *Config) *time.Duration) configField {
+	return configField{
+		key:     key,
+		aliases: []string{key + "_SECS"},
+		get:     func(c *Config) string { return ptr(c).String() },
+		set: func(c *Config, value string) error {
+			v, err := registry.ParseDuration(value, time.Second)
+			if err != nil {
+				return err
+			}
+			*ptr(c) = v
+			return nil
+		},
+	}
+}
+
+// reloadable marks a setting as safe to change at runtime.
+func reloadable(field configField) configField {
+	field.reloadable = true
+	return field
+}
+
+// configFields lists every configurable setting, in a stable order.
+var configFields = []configField{
+	reloadable(stringField("LOG_LEVEL", func(c *Config) *string { return &c.LogLevel })),
+	reloadable(stringField("LOG_FORMAT", func(c *Config) *string { return &c.LogFormat })),
+	stringField("LOG_OUTPUT", func(c *Config) *string { return &c.LogOutput }),
+	intField("LOG_FILE_MAX_SIZE_MB", func(c *Config) *int { return &c.LogFileMaxSizeMB }),
+	intField("LOG_FILE_MAX_AGE_DAYS", func(c *Config) *int { return &c.LogFileMaxAgeDays }),
+	intField("LOG_FILE_MAX_BACKUPS", func(c *Config) *int { return &c.LogFileMaxBackups }),
+	boolField("LOG_FILE_COMPRESS", func(c *Config) *bool { return &c.LogFileCompress }),
+	stringField("LOG_REDACT_KEYS", func(c *Config) *string { return &c.LogRedactKeys }),
+	boolField("LOGS_PERSIST", func(c *Config) *bool { return &c.LogsPersist }),
+	stringField("LOGS_LEVEL", func(c *Config) *string { return &c.LogsLevel }),
+	intField("LOGS_MAX_DAYS", func(c *Config) *int { return &c.LogsMaxDays }),
+	stringField("TOKENS_FILE", func(c *Config) *string { return &c.TokensFile }),
+	stringField("ADMIN_TOKEN_HASH", func(c *Config) *string { return &c.AdminTokenHash }),
+	intField("SERVER_PORT", func(c *Config) *int { return &c.ServerPort }),
+	durationField("SERVER_IDLE_TIMEOUT", func(c *Config) *time.Duration { return &c.ServerIdleTimeout }),
+	durationField("SERVER_READ_TIMEOUT", func(c *Config) *time.Duration { return &c.ServerReadTimeout }),
+	durationField("SERVER_WRITE_TIMEOUT", func(c *Config) *time.Duration { return &c.ServerWriteTimeout }),
+	durationField("SERVER_READ_HEADER_TIMEOUT", func(c *Config) *time.Duration { return &c.ServerReadHeaderTimeout }),
+	intField("SERVER_MAX_HEADER_BYTES", func(c *Config) *int { return &c.ServerMaxHeaderBytes }),
+	boolField("SERVER_H2C", func(c *Config) *bool { return &c.ServerH2C }),
+	boolField("SERVER_KEEP_ALIVES_DISABLED", func(c *Config) *bool { return &c.ServerKeepAlivesDisabled }),
+	intField("SERVER_MAX_CONNECTIONS", func(c *Config) *int { return &c.ServerMaxConnections }),
+	stringField("SERVER_SOCKET", func(c *Config) *string { return &c.ServerSocket }),
+	stringField("ADMIN_ADDR", func(c *Config) *string { return &c.AdminAddr }),
+	durationField("SERVER_SHUTDOWN_GRACE_PERIOD", func(c *Config) *time.Duration { return &c.ServerShutdownGracePeriod }),
+	durationField("SERVER_PRE_SHUTDOWN_DELAY", func(c *Config) *time.Duration { return &c.ServerPreShutdownDelay }),
+	durationField("SERVER_TERMINATE_TIMEOUT", func(c *Config) *time.Duration { return &c.ServerTerminateTimeout }),
+	boolField("ACCESS_LOG_DISABLED", func(c *Config) *bool { return &c.AccessLogDisabled }),
+	boolField("ACCESS_LOG_SKIP_HEALTH", func(c *Config) *bool { return &c.AccessLogSkipHealth }),
+	floatField("ACCESS_LOG_SAMPLE_RATE", func(c *Config) *float64 { return &c.AccessLogSampleRate }),
+	boolField("METRICS_DISABLED", func(c *Config) *bool { return &c.MetricsDisabled }),
+	intField("METRICS_PORT", func(c *Config) *int { return &c.MetricsPort }),
+	stringField("TRACING_EXPORTER", func(c *Config) *string { return &c.TracingExporter }),
+	stringField("TRACING_ENDPOINT", func(c *Config) *string { return &c.TracingEndpoint }),
+	floatField("TRACING_SAMPLE_RATIO", func(c *Config) *float64 { return &c.TracingSampleRatio }),
+	stringField("TLS_CERT_FILE", func(c *Config) *string { return &c.TLSCertFile }),
+	stringField("TLS_KEY_FILE", func(c *Config) *string { return &c.TLSKeyFile }),
+	stringField("TLS_MIN_VERSION", func(c *Config) *string { return &c.TLSMinVersion }),
+	stringField("TLS_CIPHER_SUITES", func(c *Config) *string { return &c.TLSCipherSuites }),
+	stringField("TLS_CLIENT_CA_FILE", func(c *Config) *string { return &c.TLSClientCAFile }),
+	boolField("TLS_CLIENT_CERT_REQUIRED", func(c *Config) *bool { return &c.TLSClientCertRequired }),
+}
+
+// loadConfig resolves the effective config from the defaults passed to
+// NewWithConfig and the registry layers, recording the source of every
+// setting. It returns an error listing every value that failed to parse
+// or to validate.
+func (s *Sentinel) loadConfig() error {
+	configFile := s.configFile
+	if configFile == "" {
+		configFile = os.Getenv(envConfigFile)
+	}
+
+	r, err := registry.NewLayered(registry.LayeredConfig{
+		ConfigFile: configFile,
+		EnvPrefix:  envPrefix,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load config - %w", err)
+	}
+
+	for key, value := range s.flags {
+		r.SetFlag(key, value)
+	}
+
+	config, sources, err := resolveConfig(s.defaults, r)
+	if err != nil {
+		return err
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+
+	s.configRegistry = r
+	s.config = config
+	s.configSources = sources
+
+	return nil
+}
+
+// resolveConfig reads every setting from the registry on top of the
+// given defaults and validates the result.
+func resolveConfig(defaults Config, r *registry.Layered) (Config, map[string]registry.Source, error) {
+	config := defaults
+	sources := make(map[string]registry.Source, len(configFields))
+
+	var errs []error
+
+	// invalid holds the keys that failed to parse, which are not
+	// validated again to report a single problem per setting.
+	invalid := make(map[string]bool)
+
+	for _, field := range configFields {
+		value, source, ok := r.LookupWithAliases(field.key, field.aliases...)
+
+		sources[field.key] = source
+
+		if !ok {
+			continue
+		}
+
+		if err := field.set(&config, value); err != nil {
+			invalid[field.key] = true
+			errs = append(errs, fmt.Errorf("%s (from %s): %w", field.key, source, err))
+		}
+	}
+
+	for _, problem := range config.validate() {
+		if invalid[problem.key] {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("%s (from %s): %w", problem.key, sources[problem.key], problem.err))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return Config{}, nil, fmt.Errorf("invalid config:\n%w", err)
+	}
+
+	return config, sources, nil
+}
+
+// configSourcesAttr returns the source of every setting as a log attribute.
+func (s *Sentinel) configSourcesAttr() slog.Attr {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	attrs := make([]any, 0, len(configFields))
+
+	for _, field := range configFields {
+		attrs = append(attrs, slog.String(field.key, string(s.configSources[field.key])))
+	}
+
+	return slog.Group("sources", attrs...)
+}
@@ -0,0 +1,247 @@
+package sentinel
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"github.com/dlbarduzzi/sentinel/tools/registry"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	t.Setenv("SENTINEL_LOG_FORMAT", "json")
+	t.Setenv("SENTINEL_SERVER_PORT", "9100")
+
+	config := DefaultConfig()
+	config.LogFormat = "text"
+	config.AccessLogSampleRate = 0.5
+
+	app := NewWithConfig(config)
+
+	app.configFile = "tests/config/test_sentinel.yaml"
+	app.flags["SERVER_PORT"] = "9200"
+
+	if err := app.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		key            string
+		value          any
+		expectedValue  any
+		expectedSource registry.Source
+	}{
+		{"LOG_LEVEL", app.config.LogLevel, "debug", registry.SourceFile},
+		{"LOG_FORMAT", app.config.LogFormat, "json", registry.SourceEnv},
+		{"SERVER_PORT", app.config.ServerPort, 9200, registry.SourceFlag},
+		{"METRICS_PORT", app.config.MetricsPort, 9091, registry.SourceFile},
+		{"ACCESS_LOG_SAMPLE_RATE", app.config.AccessLogSampleRate, 0.5, registry.SourceDefault},
+	}
+
+	for _, tc := range testCases {
+		if tc.value != tc.expectedValue {
+			t.Errorf("expected %s to be %v, got %v", tc.key, tc.expectedValue, tc.value)
+		}
+
+		if source := app.configSources[tc.key]; source != tc.expectedSource {
+			t.Errorf("expected %s source to be %q, got %q", tc.key, tc.expectedSource, source)
+		}
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	t.Setenv("SENTINEL_SERVER_PORT", "abc")
+	t.Setenv("SENTINEL_METRICS_DISABLED", "maybe")
+	t.Setenv("SENTINEL_LOG_LEVEL", "verbose")
+
+	app := NewWithConfig(DefaultConfig())
+
+	err := app.loadConfig()
+	if err == nil {
+		t.Fatal("expected config error, got nil")
+	}
+
+	expected := []string{
+		`SERVER_PORT (from env): invalid integer "abc"`,
+		`METRICS_DISABLED (from env): invalid boolean "maybe"`,
+		`LOG_LEVEL (from env): must be one of debug, info, warn or error, got "verbose"`,
+	}
+
+	for _, content := range expected {
+		if !strings.Contains(err.Error(), content) {
+			t.Errorf("expected error to contain %q, got \n%v", content, err)
+		}
+	}
+
+	// Settings failing to parse are not validated again.
+	if strings.Count(err.Error(), "SERVER_PORT") != 1 {
+		t.Errorf("expected a single SERVER_PORT error, got \n%v", err)
+	}
+
+	// The previous config is kept on failure.
+	if app.config.ServerPort != 8090 {
+		t.Fatalf("expected server port to be 8090, got %d", app.config.ServerPort)
+	}
+}
+
+func TestLoadConfigMissingFile(t *testing.T) {
+	app := NewWithConfig(Config{})
+	app.configFile = "tests/config/missing.yaml"
+
+	if err := app.loadConfig(); err == nil {
+		t.Fatal("expected missing config file error, got nil")
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("expected default config to be valid, got %v", err)
+	}
+
+	testCases := []struct {
+		name     string
+		modify   func(c *Config)
+		expected string
+	}{
+		{"log level", func(c *Config) { c.LogLevel = "verbose" }, "LOG_LEVEL: must be one of"},
+		{"log format", func(c *Config) { c.LogFormat = "xml" }, "LOG_FORMAT: must be one of"},
+		{"log output", func(c *Config) { c.LogOutput = " " }, "LOG_OUTPUT: must be stderr, stdout or a file path"},
+		{"log file max size", func(c *Config) { c.LogFileMaxSizeMB = -1 }, "LOG_FILE_MAX_SIZE_MB: must not be negative"},
+		{"log file max age", func(c *Config) { c.LogFileMaxAgeDays = -1 }, "LOG_FILE_MAX_AGE_DAYS: must not be negative"},
+		{"log file max backups", func(c *Config) { c.LogFileMaxBackups = -1 }, "LOG_FILE_MAX_BACKUPS: must not be negative"},
+		{"logs level", func(c *Config) { c.LogsLevel = "all" }, "LOGS_LEVEL: must be one of"},
+		{"logs max days", func(c *Config) { c.LogsMaxDays = 0 }, "LOGS_MAX_DAYS: must be greater than 0"},
+		{"missing server port", func(c *Config) { c.ServerPort = 0 }, "SERVER_PORT: must be between 1 and 65535, got 0"},
+		{"server port range", func(c *Config) { c.ServerPort = 70000 }, "SERVER_PORT: must be between 1 and 65535, got 70000"},
+		{"idle timeout", func(c *Config) { c.ServerIdleTimeout = 0 }, "SERVER_IDLE_TIMEOUT: must be greater than 0"},
+		{"read timeout", func(c *Config) { c.ServerReadTimeout = -1 }, "SERVER_READ_TIMEOUT: must be greater than 0"},
+		{"write timeout", func(c *Config) { c.ServerWriteTimeout = 0 }, "SERVER_WRITE_TIMEOUT: must be greater than 0"},
+		{"admin addr", func(c *Config) { c.AdminAddr = "localhost" }, "ADMIN_ADDR: must be a host:port address or a unix: socket path"},
+		{"read header timeout", func(c *Config) { c.ServerReadHeaderTimeout = 0 }, "SERVER_READ_HEADER_TIMEOUT: must be greater than 0"},
+		{"max header bytes", func(c *Config) { c.ServerMaxHeaderBytes = -1 }, "SERVER_MAX_HEADER_BYTES: must not be negative"},
+		{"max connections", func(c *Config) { c.ServerMaxConnections = -1 }, "SERVER_MAX_CONNECTIONS: must not be negative"},
+		{"admin port conflict", func(c *Config) { c.AdminAddr = "127.0.0.1:8090" }, "ADMIN_ADDR: must use a port different from SERVER_PORT"},
+		{"admin metrics port conflict", func(c *Config) { c.AdminAddr, c.MetricsPort = "127.0.0.1:9090", 9090 }, "ADMIN_ADDR: must use a port different from METRICS_PORT"},
+		{"shutdown grace period", func(c *Config) { c.ServerShutdownGracePeriod = 0 }, "SERVER_SHUTDOWN_GRACE_PERIOD: must be greater than 0"},
+		{"pre shutdown delay", func(c *Config) { c.ServerPreShutdownDelay = -time.Second }, "SERVER_PRE_SHUTDOWN_DELAY: must not be negative"},
+		{"terminate timeout", func(c *Config) { c.ServerTerminateTimeout = 0 }, "SERVER_TERMINATE_TIMEOUT: must be greater than 0"},
+		{"admin token hash", func(c *Config) { c.AdminTokenHash = "snt_raw" }, "ADMIN_TOKEN_HASH: must be a hex encoded SHA-256 hash"},
+		{"negative sample rate", func(c *Config) { c.AccessLogSampleRate = -0.5 }, "ACCESS_LOG_SAMPLE_RATE: must be between 0 (default of 1) and 1"},
+		{"sample rate", func(c *Config) { c.AccessLogSampleRate = 1.5 }, "ACCESS_LOG_SAMPLE_RATE: must be between 0 (default of 1) and 1"},
+		{"metrics port range", func(c *Config) { c.MetricsPort = -1 }, "METRICS_PORT: must be between 0 and 65535"},
+		{"metrics port conflict", func(c *Config) { c.MetricsPort = c.ServerPort }, "METRICS_PORT: must be different from SERVER_PORT"},
+		{"tracing exporter", func(c *Config) { c.TracingExporter = "jaeger" }, "TRACING_EXPORTER: must be one of none, stdout or otlp-http"},
+		{"tls key file", func(c *Config) { c.TLSCertFile = "cert.pem" }, "TLS_CERT_FILE: must be set together with TLS_KEY_FILE"},
+		{"tls min version", func(c *Config) { c.TLSCertFile, c.TLSKeyFile, c.TLSMinVersion = "cert.pem", "key.pem", "2.0" }, "TLS_MIN_VERSION: must be 1.2 or 1.3"},
+		{"tls insecure version", func(c *Config) { c.TLSCertFile, c.TLSKeyFile, c.TLSMinVersion = "cert.pem", "key.pem", "1.1" }, "TLS_MIN_VERSION: must be 1.2 or 1.3"},
+		{"tls http2 cipher suite", func(c *Config) {
+			c.TLSCertFile, c.TLSKeyFile, c.TLSCipherSuites = "cert.pem", "key.pem", "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"
+		}, "TLS_CIPHER_SUITES: http/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		{"tls cipher suites", func(c *Config) { c.TLSCertFile, c.TLSKeyFile, c.TLSCipherSuites = "cert.pem", "key.pem", "RC4" }, "TLS_CIPHER_SUITES: unknown or insecure cipher suite"},
+		{"tls client cert", func(c *Config) { c.TLSClientCertRequired = true }, "TLS_CLIENT_CERT_REQUIRED: requires TLS_CLIENT_CA_FILE"},
+		{"tracing sample ratio", func(c *Config) { c.TracingSampleRatio = 2 }, "TRACING_SAMPLE_RATIO: must be between 0 (default of 1) and 1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := DefaultConfig()
+			tc.modify(&config)
+
+			err := config.Validate()
+			if err == nil {
+				t.Fatal("expected validation error, got nil")
+			}
+
+			if !strings.Contains(err.Error(), tc.expected) {
+				t.Fatalf("expected error to contain %q, got %q", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestConfigValidateDefaultSampleRates(t *testing.T) {
+	config := DefaultConfig()
+	config.AccessLogSampleRate, config.TracingSampleRatio = 0, 0
+
+	if err := config.Validate(); err != nil {
+		t.Fatalf("expected the zero sample rates to use the default, got %v", err)
+	}
+}
+
+func TestConfigValidateAggregatesErrors(t *testing.T) {
+	err := Config{}.Validate()
+	if err == nil {
+		t.Fatal("expected validation error, got nil")
+	}
+
+	// Every setting with no valid zero value is reported at once.
+	if lines := strings.Split(err.Error(), "\n"); len(lines) != 13 {
+		t.Fatalf("expected 13 errors, got %d: \n%v", len(lines), err)
+	}
+}
+
+func TestLoadConfigDurations(t *testing.T) {
+	t.Setenv("SENTINEL_SERVER_IDLE_TIMEOUT", "1m30s")
+	t.Setenv("SENTINEL_SERVER_READ_TIMEOUT", "250ms")
+
+	// Bare integers of the former keys are read as seconds.
+	t.Setenv("SENTINEL_SERVER_WRITE_TIMEOUT_SECS", "10")
+
+	app := NewWithConfig(DefaultConfig())
+
+	if err := app.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		key      string
+		value    time.Duration
+		expected time.Duration
+	}{
+		{"SERVER_IDLE_TIMEOUT", app.config.ServerIdleTimeout, 90 * time.Second},
+		{"SERVER_READ_TIMEOUT", app.config.ServerReadTimeout, 250 * time.Millisecond},
+		{"SERVER_WRITE_TIMEOUT", app.config.ServerWriteTimeout, 10 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		if tc.value != tc.expected {
+			t.Errorf("expected %s to be %v, got %v", tc.key, tc.expected, tc.value)
+		}
+
+		if source := app.configSources[tc.key]; source != registry.SourceEnv {
+			t.Errorf("expected %s source to be %q, got %q", tc.key, registry.SourceEnv, source)
+		}
+	}
+}
+
+func TestLoadConfigInvalidDuration(t *testing.T) {
+	t.Setenv("SENTINEL_SERVER_IDLE_TIMEOUT", "5 seconds")
+
+	err := NewWithConfig(DefaultConfig()).loadConfig()
+	if err == nil {
+		t.Fatal("expected config error, got nil")
+	}
+
+	expected := `SERVER_IDLE_TIMEOUT (from env): invalid duration "5 seconds"`
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected error to contain %q, got \n%v", expected, err)
+	}
+}
+
+func TestConfigLogRedactConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.LogRedactKeys = "webhook_url, api_key,"
+
+	redact := config.logRedactConfig()
+
+	expected := append(slices.Clone(logging.DefaultRedactKeys), "webhook_url", "api_key")
+	if !slices.Equal(redact.Keys, expected) {
+		t.Fatalf("expected redact keys to be %v, got %v", expected, redact.Keys)
+	}
+
+	if len(logging.DefaultRedactKeys) != 4 {
+		t.Fatalf("expected default redact keys not to be modified, got %v", logging.DefaultRedactKeys)
+	}
+}
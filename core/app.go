@@ -1,14 +1,86 @@
 package core
 
-import "log/slog"
+import (
+	"context"
+	"log/slog"
+
+	"github.com/dlbarduzzi/sentinel/tools/health"
+	"github.com/dlbarduzzi/sentinel/tools/hook"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Logger components whose level can be changed independently, see
+// App.LogLevels and logging.Component.
+const (
+	LogComponentHTTP  = "http"
+	LogComponentSync  = "sync"
+	LogComponentStore = "store"
+)
+
+// LogComponents lists the known logger components.
+var LogComponents = []string{LogComponentHTTP, LogComponentSync, LogComponentStore}
 
 type App interface {
 	// Logger returns the default app logger.
 	Logger() *slog.Logger
 
+	// LogLevels controls the level of the app logger and of its components
+	// at runtime.
+	LogLevels() *logging.Levels
+
+	// LogStore returns the store of the persisted logs, or nil when the
+	// logs are not persisted.
+	LogStore() LogStore
+
+	// TokenStore returns the store of the api tokens.
+	TokenStore() TokenStore
+
+	// Metrics returns the app metrics registry and collectors.
+	Metrics() *Metrics
+
+	// HealthChecks returns the registry of the liveness and readiness checks.
+	HealthChecks() *health.Registry
+
+	// TracerProvider returns the provider of the app tracers.
+	TracerProvider() trace.TracerProvider
+
+	// Go runs fn in a background job drained on shutdown, see Drain.
+	Go(name string, fn func(ctx context.Context) error)
+
+	// Drain cancels the background jobs context and waits for the jobs
+	// to return. It runs before Terminate.
+	Drain(ctx context.Context) error
+
 	// Bootstrap initializes the application.
 	Bootstrap() error
 
-	// OnShutdown run jobs before the application shuts down.
-	OnShutdown()
+	// Terminate runs the OnTerminate hooks before the application shuts down.
+	Terminate(ctx context.Context) error
+
+	// OnBootstrap hook is triggered when initializing the application.
+	OnBootstrap() *hook.Hook[*BootstrapEvent]
+
+	// OnServe hook is triggered right before the http server starts.
+	OnServe() *hook.Hook[*ServeEvent]
+
+	// OnTerminate hook is triggered before the application shuts down.
+	OnTerminate() *hook.Hook[*TerminateEvent]
+
+	// OnConfigReload hook is triggered when the config changes at runtime.
+	// The changes are applied after e.Next() is called and a handler error
+	// rejects the whole reload.
+	OnConfigReload() *hook.Hook[*ConfigReloadEvent]
+
+	// OnRequest hook is triggered on every api request, before the route
+	// handler. Handlers must call e.Next() to continue the request.
+	OnRequest() *hook.Hook[*EventRequest]
+
+	// OnRuleGroupCreate hook is triggered when a rule group is created.
+	// Passing tags limits the bound handlers to the given rule group names.
+	OnRuleGroupCreate(tags ...string) *hook.TaggedHook[*RuleGroupEvent]
+
+	// OnClusterSync hook is triggered when the rules of a cluster are synced.
+	// Passing tags limits the bound handlers to the given cluster names.
+	OnClusterSync(tags ...string) *hook.TaggedHook[*ClusterSyncEvent]
 }
@@ -1,17 +1,29 @@
 package core
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dlbarduzzi/sentinel/tools/health"
+	"github.com/dlbarduzzi/sentinel/tools/hook"
 	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 const (
 	defaultLogLevel  = "info"
 	defaultLogFormat = "json"
+
+	defaultLogsMaxDays     = 7
+	defaultLogsCleanupTick = time.Hour
 )
 
 // BaseAppConfig defines a BaseApp configuration option.
@@ -19,6 +31,37 @@ type BaseAppConfig struct {
 	LogLevel    string
 	LogFormat   string
 	LogDisabled bool
+
+	// LogOutput is stderr (default), stdout or a file path rotated with
+	// LogRotation.
+	LogOutput   string
+	LogRotation logging.RotationConfig
+
+	// LogSinks replaces LogOutput to write the logs to several outputs,
+	// each with its own level and format.
+	LogSinks []logging.SinkConfig
+
+	// LogRedact masks the sensitive log values, see logging.Config.Redact.
+	LogRedact *logging.RedactConfig
+
+	// LogsPersist saves the logs of LogsLevel and above (default info)
+	// to LogsStore (default a MemoryLogStore) for LogsMaxDays (default 7).
+	LogsPersist bool
+	LogsLevel   string
+	LogsMaxDays int
+	LogsStore   LogStore
+
+	// TokensStore persists the api tokens, a MemoryTokenStore by default.
+	TokensStore TokenStore
+
+	// AdminTokenHash is the hex encoded SHA-256 of a raw token stored
+	// with the admin scope on Bootstrap, see HashToken. It creates the
+	// first token without going through the api.
+	AdminTokenHash string
+
+	// TracerProvider creates the request and store spans. Tracing is
+	// disabled when nil.
+	TracerProvider trace.TracerProvider
 }
 
 // Ensures that the BaseApp implements the App interface.
@@ -26,15 +69,64 @@ var _ App = (*BaseApp)(nil)
 
 // BaseApp implements core.App and defines the base Sentinel app structure.
 type BaseApp struct {
-	logger *slog.Logger
-	config *BaseAppConfig
+	logger    atomic.Pointer[slog.Logger]
+	logLevels *logging.Levels
+
+	// logFiles holds the log files of the app loggers, closed by Terminate.
+	logFiles *logging.Files
+
+	// logsHandler persists the logs when LogsPersist is enabled.
+	logsHandler *logging.BatchHandler
+	logsCancel  context.CancelFunc
+
+	// configMu guards the config fields changed at runtime, see
+	// SetLogLevel and SetLogFormat.
+	configMu sync.RWMutex
+	config   *BaseAppConfig
+
+	metrics *Metrics
+	health  *health.Registry
+
+	// jobs tracks the background jobs started with Go, jobsCtx is
+	// canceled when they are drained.
+	jobsMu       sync.Mutex
+	jobs         sync.WaitGroup
+	jobsCtx      context.Context
+	jobsCancel   context.CancelFunc
+	jobsDraining bool
+
+	onBootstrap *hook.Hook[*BootstrapEvent]
+	onServe     *hook.Hook[*ServeEvent]
+	onTerminate *hook.Hook[*TerminateEvent]
+
+	onConfigReload *hook.Hook[*ConfigReloadEvent]
+
+	onRequest         *hook.Hook[*EventRequest]
+	onRuleGroupCreate *hook.Hook[*RuleGroupEvent]
+	onClusterSync     *hook.Hook[*ClusterSyncEvent]
 }
 
 func NewBaseApp(config BaseAppConfig) *BaseApp {
 	app := &BaseApp{
-		config: &config,
+		config:    &config,
+		logLevels: logging.NewLevels(logging.LogLevel(config.LogLevel)),
+		logFiles:  logging.NewFiles(),
+		metrics:   newMetrics(),
+		health:    health.NewRegistry(),
+
+		onBootstrap: &hook.Hook[*BootstrapEvent]{},
+		onServe:     &hook.Hook[*ServeEvent]{},
+		onTerminate: &hook.Hook[*TerminateEvent]{},
+
+		onConfigReload: &hook.Hook[*ConfigReloadEvent]{},
+
+		onRequest:         &hook.Hook[*EventRequest]{},
+		onRuleGroupCreate: &hook.Hook[*RuleGroupEvent]{},
+		onClusterSync:     &hook.Hook[*ClusterSyncEvent]{},
 	}
 
+	app.jobsCtx, app.jobsCancel = context.WithCancel(context.Background())
+
 	if app.config.LogLevel == "" {
 		app.config.LogLevel = defaultLogLevel
 	}
@@ -43,48 +135,378 @@ func NewBaseApp(config BaseAppConfig) *BaseApp {
 		app.config.LogFormat = defaultLogFormat
 	}
 
+	if app.config.LogsLevel == "" {
+		app.config.LogsLevel = defaultLogLevel
+	}
+
+	if app.config.LogsMaxDays < 1 {
+		app.config.LogsMaxDays = defaultLogsMaxDays
+	}
+
+	if app.config.TokensStore == nil {
+		app.config.TokensStore = NewMemoryTokenStore()
+	}
+
 	return app
 }
 
+// Config returns the app configuration. Changes to it must be done
+// before Bootstrap to take effect. The log level and format are changed
+// afterwards with SetLogLevel and SetLogFormat.
+func (app *BaseApp) Config() *BaseAppConfig {
+	return app.config
+}
+
+// SetLogLevel changes the configured log level and the permanent root
+// level of LogLevels, see Levels.SetBase. A temporary level set with a
+// ttl, e.g. by an admin, is kept until it expires. It is safe to call
+// concurrently, e.g. on a config reload.
+func (app *BaseApp) SetLogLevel(level logging.LogLevel) {
+	app.configMu.Lock()
+	app.config.LogLevel = string(level)
+	app.configMu.Unlock()
+
+	app.logLevels.SetBase(level)
+}
+
+// SetLogFormat changes the configured log format and reloads the app
+// logger, see ReloadLogger. It is safe to call concurrently.
+func (app *BaseApp) SetLogFormat(format logging.LogFormat) error {
+	app.configMu.Lock()
+	app.config.LogFormat = string(format)
+	app.configMu.Unlock()
+
+	return app.ReloadLogger()
+}
+
 // Logger returns the default app logger.
 func (app *BaseApp) Logger() *slog.Logger {
-	if app.logger == nil {
-		return slog.Default()
+	if logger := app.logger.Load(); logger != nil {
+		return logger
+	}
+	return slog.Default()
+}
+
+// LogLevels controls the level of the app logger and of its components
+// at runtime.
+func (app *BaseApp) LogLevels() *logging.Levels {
+	return app.logLevels
+}
+
+// ReloadLogger replaces the app logger with one built from the current
+// app config, e.g. after a config reload changed the log format. Loggers
+// previously derived from the app logger keep the former format. The
+// level is not affected, see LogLevels.
+func (app *BaseApp) ReloadLogger() error {
+	return app.initLogger()
+}
+
+// LogStore returns the store of the persisted logs, or nil when the logs
+// are not persisted. Every call to the store starts a span.
+func (app *BaseApp) LogStore() LogStore {
+	if !app.config.LogsPersist || app.config.LogsStore == nil {
+		return nil
+	}
+	return &tracedLogStore{
+		store:  app.config.LogsStore,
+		tracer: app.TracerProvider().Tracer(tracerName),
+	}
+}
+
+// TokenStore returns the store of the api tokens. Every call to the store
+// starts a span.
+func (app *BaseApp) TokenStore() TokenStore {
+	return &tracedTokenStore{
+		store:  app.config.TokensStore,
+		tracer: app.TracerProvider().Tracer(tracerName),
+	}
+}
+
+// Metrics returns the app metrics registry and collectors.
+func (app *BaseApp) Metrics() *Metrics {
+	return app.metrics
+}
+
+// HealthChecks returns the registry of the liveness and readiness checks.
+func (app *BaseApp) HealthChecks() *health.Registry {
+	return app.health
+}
+
+// TracerProvider returns the configured tracer provider, or a no-op
+// provider when tracing is disabled.
+func (app *BaseApp) TracerProvider() trace.TracerProvider {
+	if app.config.TracerProvider == nil {
+		return noop.NewTracerProvider()
+	}
+	return app.config.TracerProvider
+}
+
+// Go runs fn in a background job tracked by the app. The job context is
+// canceled when the app drains its jobs on shutdown, before the
+// OnTerminate hooks run. Jobs started while draining are not run.
+func (app *BaseApp) Go(name string, fn func(ctx context.Context) error) {
+	app.jobsMu.Lock()
+	defer app.jobsMu.Unlock()
+
+	if app.jobsDraining {
+		app.Logger().Warn("background job not started, the app is shutting down", slog.String("job", name))
+		return
+	}
+
+	app.jobs.Go(func() {
+		if err := fn(app.jobsCtx); err != nil && !errors.Is(err, context.Canceled) {
+			app.Logger().Error("background job failed",
+				slog.String("job", name),
+				slog.String("error", err.Error()),
+			)
+		}
+	})
+}
+
+// Drain cancels the context of the background jobs and waits until they
+// return or ctx is done.
+func (app *BaseApp) Drain(ctx context.Context) error {
+	app.jobsMu.Lock()
+	app.jobsDraining = true
+	app.jobsMu.Unlock()
+
+	app.jobsCancel()
+
+	done := make(chan struct{})
+
+	go func() {
+		app.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to drain background jobs: %w", ctx.Err())
 	}
-	return app.logger
 }
 
 // Bootstrap initializes the application.
 func (app *BaseApp) Bootstrap() error {
-	if err := app.initLogger(); err != nil {
-		return err
+	event := &BootstrapEvent{App: app}
+
+	return app.OnBootstrap().Trigger(event, func(e *BootstrapEvent) error {
+		app.logLevels.Set("", logging.LogLevel(app.config.LogLevel), 0)
+
+		if err := app.initAdminToken(); err != nil {
+			return err
+		}
+
+		app.initLogs()
+
+		if err := app.initLogger(); err != nil {
+			return err
+		}
+
+		return e.Next()
+	})
+}
+
+// Terminate runs the OnTerminate hooks before the application shuts down.
+// It waits for the jobs started with TerminateEvent.Go until ctx is done
+// and logs every failure before returning them.
+func (app *BaseApp) Terminate(ctx context.Context) error {
+	event := &TerminateEvent{App: app, Context: ctx}
+
+	err := app.OnTerminate().Trigger(event)
+	err = errors.Join(err, event.wait())
+
+	// The logs are flushed last so they include the terminate failures.
+	if app.logsHandler != nil {
+		app.logsCancel()
+
+		if closeErr := app.logsHandler.Close(ctx); closeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to persist logs: %w", closeErr))
+		}
 	}
 
-	return nil
+	if err != nil {
+		app.Logger().Error("failed to terminate app", slog.String("error", err.Error()))
+	}
+
+	if closeErr := app.logFiles.Close(); closeErr != nil {
+		err = errors.Join(err, closeErr)
+	}
+
+	return err
 }
 
-// OnShutdown run jobs before the application shuts down.
-func (app *BaseApp) OnShutdown() {
-	func() {
-		fmt.Println("...running first shutdown func()...")
-		time.Sleep(time.Millisecond * 10)
-	}()
-	func() {
-		fmt.Println("...running second shutdown func()...")
-		time.Sleep(time.Millisecond * 10)
-	}()
+// OnBootstrap hook is triggered when initializing the application.
+func (app *BaseApp) OnBootstrap() *hook.Hook[*BootstrapEvent] {
+	return app.onBootstrap
+}
+
+// OnServe hook is triggered right before the http server starts.
+func (app *BaseApp) OnServe() *hook.Hook[*ServeEvent] {
+	return app.onServe
+}
+
+// OnTerminate hook is triggered before the application shuts down.
+func (app *BaseApp) OnTerminate() *hook.Hook[*TerminateEvent] {
+	return app.onTerminate
+}
+
+// OnConfigReload hook is triggered when the config changes at runtime.
+// The changes are applied after e.Next() is called and a handler error
+// rejects the whole reload.
+func (app *BaseApp) OnConfigReload() *hook.Hook[*ConfigReloadEvent] {
+	return app.onConfigReload
+}
+
+// OnRequest hook is triggered on every api request, before the route
+// handler. Handlers must call e.Next() to continue the request.
+func (app *BaseApp) OnRequest() *hook.Hook[*EventRequest] {
+	return app.onRequest
+}
+
+// OnRuleGroupCreate hook is triggered when a rule group is created.
+// Passing tags limits the bound handlers to the given rule group names.
+func (app *BaseApp) OnRuleGroupCreate(tags ...string) *hook.TaggedHook[*RuleGroupEvent] {
+	return hook.NewTaggedHook(app.onRuleGroupCreate, tags...)
+}
+
+// OnClusterSync hook is triggered when the rules of a cluster are synced.
+// Passing tags limits the bound handlers to the given cluster names.
+func (app *BaseApp) OnClusterSync(tags ...string) *hook.TaggedHook[*ClusterSyncEvent] {
+	return hook.NewTaggedHook(app.onClusterSync, tags...)
 }
 
 func (app *BaseApp) initLogger() error {
-	app.logger = logging.NewLoggerWithConfig(logging.Config{
-		Level:    logging.LogLevel(app.config.LogLevel),
-		Format:   logging.LogFormat(app.config.LogFormat),
+	var handlers []slog.Handler
+	if app.logsHandler != nil {
+		handlers = append(handlers, app.logsHandler)
+	}
+
+	app.configMu.RLock()
+	level, format := app.config.LogLevel, app.config.LogFormat
+	app.configMu.RUnlock()
+
+	logger, err := logging.OpenLogger(logging.Config{
+		Level:    logging.LogLevel(level),
+		Format:   logging.LogFormat(format),
 		Disabled: app.config.LogDisabled,
-	}).With(slog.String("app", "sentinel"))
+		Levels:   app.logLevels,
+		Output:   app.config.LogOutput,
+		Rotation: app.config.LogRotation,
+		Sinks:    app.config.LogSinks,
+		Files:    app.logFiles,
+		Redact:   app.config.LogRedact,
+		Handlers: handlers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	app.logger.Store(logger.With(slog.String("app", "sentinel")))
+
+	return nil
+}
+
+// adminTokenID is the id of the token stored from AdminTokenHash.
+const adminTokenID = "admin"
+
+// initAdminToken stores the AdminTokenHash token, replacing the one of a
+// previous hash.
+func (app *BaseApp) initAdminToken() error {
+	hash := strings.ToLower(app.config.AdminTokenHash)
+	if hash == "" {
+		return nil
+	}
+
+	ctx := context.Background()
+	store := app.config.TokensStore
+
+	_, err := store.FindTokenByHash(ctx, hash)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to find admin token: %w", err)
+	}
+
+	if err := store.DeleteToken(ctx, adminTokenID); err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to replace admin token: %w", err)
+	}
 
-	if app.logger == nil {
-		return errors.New("logger not initialized")
+	token := &Token{
+		ID:      adminTokenID,
+		Name:    "admin",
+		Owner:   "admin",
+		Scopes:  []string{ScopeAdmin},
+		Hash:    hash,
+		Created: time.Now().UTC(),
+	}
+
+	if err := store.SaveToken(ctx, token); err != nil {
+		return fmt.Errorf("failed to save admin token: %w", err)
 	}
 
 	return nil
 }
+
+// initLogs starts persisting the logs when enabled, and removing the ones
+// older than LogsMaxDays until Terminate.
+func (app *BaseApp) initLogs() {
+	if !app.config.LogsPersist || app.logsHandler != nil {
+		return
+	}
+
+	if app.config.LogsStore == nil {
+		app.config.LogsStore = NewMemoryLogStore(DefaultMemoryLogStoreSize)
+	}
+
+	store := app.config.LogsStore
+
+	app.logsHandler = logging.NewBatchHandler(logging.BatchConfig{
+		Level:  logging.SlogLevel(logging.LogLevel(app.config.LogsLevel)),
+		Redact: app.config.LogRedact,
+		Write: func(ctx context.Context, records []logging.Record) error {
+			logs := make([]*Log, len(records))
+			for i, record := range records {
+				logs[i] = &Log{
+					Created: record.Time.UTC(),
+					Level:   logging.LogLevel(strings.ToLower(record.Level.String())),
+					Message: record.Message,
+					Data:    record.Attrs,
+				}
+			}
+			return store.SaveLogs(ctx, logs)
+		},
+		OnError: func(err error) {
+			// Not logged through the app logger to avoid a loop.
+			fmt.Fprintf(os.Stderr, "failed to persist logs: %v\n", err)
+		},
+		OnDrop: func(n uint64) {
+			app.metrics.LogsDropped.Add(float64(n))
+			fmt.Fprintf(os.Stderr, "failed to persist logs: %d logs dropped, the log store is too slow\n", n)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app.logsCancel = cancel
+
+	go func() {
+		ticker := time.NewTicker(defaultLogsCleanupTick)
+		defer ticker.Stop()
+
+		for {
+			before := time.Now().AddDate(0, 0, -app.config.LogsMaxDays)
+
+			if err := store.DeleteLogsBefore(ctx, before); err != nil && ctx.Err() == nil {
+				app.Logger().Error("failed to delete old logs", slog.String("error", err.Error()))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+func TestBaseAppBootstrap(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+
+	calls := ""
+
+	app.OnBootstrap().BindFunc(func(e *BootstrapEvent) error {
+		calls += "a"
+
+		if err := e.Next(); err != nil {
+			return err
+		}
+
+		if app.logger.Load() == nil {
+			t.Error("expected logger to be initialized after e.Next()")
+		}
+
+		calls += "c"
+
+		return nil
+	})
+
+	app.OnBootstrap().BindFunc(func(e *BootstrapEvent) error {
+		calls += "b"
+		return e.Next()
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != "abc" {
+		t.Fatalf("expected calls to be %q, got %q", "abc", calls)
+	}
+}
+
+func TestBaseAppAdminTokenHash(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTokenStore()
+
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true, TokensStore: store, AdminTokenHash: HashToken("snt_first")})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := store.FindTokenByHash(ctx, HashToken("snt_first"))
+	if err != nil {
+		t.Fatalf("expected the admin token to be stored, got %v", err)
+	}
+
+	if !token.HasScope(ScopeAdmin) {
+		t.Fatalf("expected the admin token to have the admin scope, got %v", token.Scopes)
+	}
+
+	// A new hash replaces the previous admin token.
+	app.Config().AdminTokenHash = strings.ToUpper(HashToken("snt_second"))
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.FindTokenByHash(ctx, HashToken("snt_first")); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected the previous admin token to be replaced, got %v", err)
+	}
+
+	if tokens, _ := store.FindTokens(ctx); len(tokens) != 1 || tokens[0].Hash != HashToken("snt_second") {
+		t.Fatalf("expected a single admin token, got %v", tokens)
+	}
+}
+
+func TestNewBaseAppTokenStore(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+
+	if app.TokenStore() == nil {
+		t.Fatal("expected a default token store before Bootstrap")
+	}
+}
+
+func TestBaseAppBootstrapError(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+	errTest := errors.New("test")
+
+	app.OnBootstrap().BindFunc(func(*BootstrapEvent) error {
+		return errTest
+	})
+
+	if err := app.Bootstrap(); !errors.Is(err, errTest) {
+		t.Fatalf("expected error %v, got %v", errTest, err)
+	}
+
+	if app.logger.Load() != nil {
+		t.Fatal("expected logger not to be initialized")
+	}
+}
+
+func TestBaseAppTerminate(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	var jobs atomic.Int32
+
+	app.OnTerminate().BindFunc(func(e *TerminateEvent) error {
+		e.Go("first", func(context.Context) error {
+			time.Sleep(time.Millisecond * 10)
+			jobs.Add(1)
+			return nil
+		})
+		e.Go("second", func(context.Context) error {
+			jobs.Add(1)
+			return errors.New("failed")
+		})
+		return e.Next()
+	})
+
+	err := app.Terminate(t.Context())
+
+	if jobs.Load() != 2 {
+		t.Fatalf("expected 2 finished jobs, got %d", jobs.Load())
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "second: failed") {
+		t.Fatalf("expected second job error, got %v", err)
+	}
+}
+
+func TestBaseAppTerminateDeadline(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	app.OnTerminate().BindFunc(func(e *TerminateEvent) error {
+		e.Go("stuck", func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		})
+		return e.Next()
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*10)
+	defer cancel()
+
+	if err := app.Terminate(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBaseAppDrain(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+
+	var jobs atomic.Int32
+
+	started := make(chan struct{})
+
+	app.Go("loop", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		time.Sleep(time.Millisecond * 10)
+		jobs.Add(1)
+		return ctx.Err()
+	})
+
+	<-started
+
+	if err := app.Drain(t.Context()); err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+
+	if jobs.Load() != 1 {
+		t.Fatal("expected drain to wait for the running job")
+	}
+
+	app.Go("late", func(context.Context) error {
+		jobs.Add(1)
+		return nil
+	})
+
+	if err := app.Drain(t.Context()); err != nil || jobs.Load() != 1 {
+		t.Fatalf("expected jobs started while draining not to run, got %d (%v)", jobs.Load(), err)
+	}
+}
+
+func TestBaseAppDrainDeadline(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+
+	app.Go("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Millisecond*10)
+	defer cancel()
+
+	if err := app.Drain(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestBaseAppLogLevels(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogLevel: "info"})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	logger := app.Logger()
+
+	if logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected debug level to be disabled")
+	}
+
+	// Level changes apply to the loggers already in use.
+	app.LogLevels().Set("", logging.LevelDebug, 0)
+
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected debug level to be enabled")
+	}
+
+	// Reloading the logger keeps the runtime level.
+	app.Config().LogFormat = "text"
+
+	if err := app.ReloadLogger(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !app.Logger().Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected debug level to be enabled after reload")
+	}
+}
+
+func TestBaseAppSetLogLevelAndFormat(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogLevel: "info", LogDisabled: true})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+
+	// The setters run while the app logs, as on a config reload.
+	go func() {
+		defer close(done)
+
+		for i := range 100 {
+			app.SetLogLevel(logging.LevelDebug)
+			if err := app.SetLogFormat([]logging.LogFormat{"json", "text"}[i%2]); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+
+	for range 100 {
+		app.Logger().Info("test")
+	}
+
+	<-done
+
+	if level := app.LogLevels().Level("").Level; level != logging.LevelDebug {
+		t.Fatalf("expected the root level to be debug, got %q", level)
+	}
+
+	if app.Config().LogLevel != "debug" || app.Config().LogFormat != "text" {
+		t.Fatalf("expected the config to be updated, got %q and %q", app.Config().LogLevel, app.Config().LogFormat)
+	}
+}
@@ -0,0 +1,12 @@
+package core
+
+import "errors"
+
+var (
+	// ErrNotFound reports that a requested resource does not exist.
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrConflict reports that a resource conflicts with its current state,
+	// e.g. a duplicated unique name.
+	ErrConflict = errors.New("resource conflict")
+)
@@ -1,8 +1,150 @@
 package core
 
-import "github.com/dlbarduzzi/sentinel/tools/event"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"github.com/dlbarduzzi/sentinel/tools/event"
+	"github.com/dlbarduzzi/sentinel/tools/hook"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
 
 type EventRequest struct {
 	App App
 	event.Event
 }
+
+// Logger returns the request scoped logger, falling back to the app logger
+// when the request was not decorated with one.
+func (e *EventRequest) Logger() *slog.Logger {
+	return logging.LoggerFromContextOr(e.Request.Context(), e.App.Logger())
+}
+
+// Auth returns the api token authenticating the request, or nil for the
+// anonymous requests.
+func (e *EventRequest) Auth() *Token {
+	return TokenFromContext(e.Request.Context())
+}
+
+// ClientCert returns the identity of the client certificate verified by
+// the server, or nil when the client did not present one or the request
+// was not served over TLS.
+func (e *EventRequest) ClientCert() *CertIdentity {
+	state := e.Request.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return newCertIdentity(state.VerifiedChains[0][0])
+}
+
+// ClusterSyncEvent is triggered when the rules of a cluster are synced.
+// It is tagged with the cluster name.
+type ClusterSyncEvent struct {
+	hook.Event
+	App     App
+	Context context.Context
+	Cluster string
+}
+
+// Tags returns the event tags used by OnClusterSync tagged hooks.
+func (e *ClusterSyncEvent) Tags() []string {
+	return []string{e.Cluster}
+}
+
+// RuleGroupEvent is triggered on rule group changes. It is tagged with
+// the rule group name.
+type RuleGroupEvent struct {
+	hook.Event
+	App       App
+	Context   context.Context
+	RuleGroup string
+}
+
+// Tags returns the event tags used by the rule group tagged hooks.
+func (e *RuleGroupEvent) Tags() []string {
+	return []string{e.RuleGroup}
+}
+
+// BootstrapEvent is triggered by App.Bootstrap. The app resources, such as
+// the logger, are only initialized after e.Next() is called.
+type BootstrapEvent struct {
+	hook.Event
+	App App
+}
+
+// ServeEvent is triggered before the http server starts listening. The
+// server and routes can be customized before calling e.Next(), which
+// blocks until the server is stopped.
+//
+// Router holds the public routes and AdminRouter the routes only served
+// by the admin listeners, which require a token with the admin scope.
+type ServeEvent struct {
+	hook.Event
+	App         App
+	Router      Router
+	AdminRouter Router
+	Server      *http.Server
+}
+
+// ConfigReloadEvent is triggered when the config file or the .env file
+// changes at runtime. Changed lists the keys applied once e.Next() is
+// called and Rejected the changed keys that require a restart.
+type ConfigReloadEvent struct {
+	hook.Event
+	App      App
+	Changed  []string
+	Rejected []string
+}
+
+// TerminateEvent is triggered by App.Terminate before the app shuts down.
+// Handlers must respect the Context deadline.
+type TerminateEvent struct {
+	hook.Event
+	App     App
+	Context context.Context
+
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	errs []error
+}
+
+// Go runs fn concurrently with the remaining terminate handlers. It is
+// meant for independent shutdown jobs; the app waits for all of them
+// before exiting, as long as the Context deadline allows it.
+func (e *TerminateEvent) Go(name string, fn func(ctx context.Context) error) {
+	e.wg.Go(func() {
+		if err := fn(e.Context); err != nil {
+			e.mu.Lock()
+			e.errs = append(e.errs, fmt.Errorf("%s: %w", name, err))
+			e.mu.Unlock()
+		}
+	})
+}
+
+// wait blocks until every job started with Go returns or the event
+// context is done, and returns the jobs errors.
+func (e *TerminateEvent) wait() error {
+	done := make(chan struct{})
+
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-e.Context.Done():
+		e.mu.Lock()
+		e.errs = append(e.errs, fmt.Errorf("terminate jobs interrupted: %w", e.Context.Err()))
+		e.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return errors.Join(e.errs...)
+}
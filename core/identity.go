@@ -0,0 +1,45 @@
+package core
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+)
+
+// CertIdentity is the identity of a verified client certificate, e.g. of
+// a cluster agent authenticated with mTLS.
+type CertIdentity struct {
+	// CommonName and Organization are read from the certificate subject.
+	CommonName   string   `json:"common_name"`
+	Organization []string `json:"organization,omitempty"`
+
+	// DNSNames, URIs (e.g. SPIFFE ids) and EmailAddresses are read from
+	// the certificate subject alternative names.
+	DNSNames       []string `json:"dns_names,omitempty"`
+	URIs           []string `json:"uris,omitempty"`
+	EmailAddresses []string `json:"email_addresses,omitempty"`
+
+	SerialNumber string `json:"serial_number"`
+
+	// Fingerprint is the hex encoded SHA-256 of the certificate.
+	Fingerprint string `json:"fingerprint"`
+}
+
+func newCertIdentity(cert *x509.Certificate) *CertIdentity {
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return &CertIdentity{
+		CommonName:     cert.Subject.CommonName,
+		Organization:   cert.Subject.Organization,
+		DNSNames:       cert.DNSNames,
+		URIs:           uris,
+		EmailAddresses: cert.EmailAddresses,
+		SerialNumber:   cert.SerialNumber.String(),
+		Fingerprint:    hex.EncodeToString(fingerprint[:]),
+	}
+}
@@ -0,0 +1,139 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+// DefaultMemoryLogStoreSize is the number of logs kept by the memory
+// store used when BaseAppConfig.LogsStore is not set.
+const DefaultMemoryLogStoreSize = 10000
+
+// Log is a log record persisted by the app when BaseAppConfig.LogsPersist
+// is enabled.
+type Log struct {
+	ID      string           `json:"id"`
+	Created time.Time        `json:"created"`
+	Level   logging.LogLevel `json:"level"`
+	Message string           `json:"message"`
+	Data    map[string]any   `json:"data"`
+}
+
+// RequestID returns the id of the request that emitted the log, if any.
+func (l *Log) RequestID() string {
+	id, _ := l.Data["request_id"].(string)
+	return id
+}
+
+// LogsQuery filters the logs returned by LogStore.FindLogs.
+type LogsQuery struct {
+	// Level is the minimum level of the logs.
+	Level     logging.LogLevel
+	Since     time.Time
+	RequestID string
+
+	// Limit is the maximum number of logs, the most recent first.
+	Limit int
+}
+
+// match reports whether the log matches the query filters.
+func (q LogsQuery) match(l *Log) bool {
+	if q.Level != "" && logging.SlogLevel(l.Level) < logging.SlogLevel(q.Level) {
+		return false
+	}
+
+	if !q.Since.IsZero() && l.Created.Before(q.Since) {
+		return false
+	}
+
+	if q.RequestID != "" && l.RequestID() != q.RequestID {
+		return false
+	}
+
+	return true
+}
+
+// LogStore persists the app logs.
+type LogStore interface {
+	// SaveLogs stores a batch of logs.
+	SaveLogs(ctx context.Context, logs []*Log) error
+
+	// FindLogs returns the logs matching the query, the most recent first.
+	FindLogs(ctx context.Context, query LogsQuery) ([]*Log, error)
+
+	// DeleteLogsBefore removes the logs created before the given time.
+	DeleteLogsBefore(ctx context.Context, before time.Time) error
+}
+
+// Ensures that the MemoryLogStore implements the LogStore interface.
+var _ LogStore = (*MemoryLogStore)(nil)
+
+// MemoryLogStore is a LogStore keeping the most recent logs in memory.
+type MemoryLogStore struct {
+	mu   sync.RWMutex
+	logs []*Log
+	size int
+}
+
+// NewMemoryLogStore creates a store keeping up to size logs, dropping the
+// oldest ones past that.
+func NewMemoryLogStore(size int) *MemoryLogStore {
+	if size < 1 {
+		size = DefaultMemoryLogStoreSize
+	}
+	return &MemoryLogStore{size: size}
+}
+
+func (s *MemoryLogStore) SaveLogs(_ context.Context, logs []*Log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, l := range logs {
+		if l.ID == "" {
+			l.ID = rand.Text()
+		}
+	}
+
+	s.logs = append(s.logs, logs...)
+
+	if len(s.logs) > s.size {
+		s.logs = slices.Clone(s.logs[len(s.logs)-s.size:])
+	}
+
+	return nil
+}
+
+func (s *MemoryLogStore) FindLogs(_ context.Context, query LogsQuery) ([]*Log, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var logs []*Log
+
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(logs) >= query.Limit {
+			break
+		}
+
+		if query.match(s.logs[i]) {
+			logs = append(logs, s.logs[i])
+		}
+	}
+
+	return logs, nil
+}
+
+func (s *MemoryLogStore) DeleteLogsBefore(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.logs = slices.DeleteFunc(s.logs, func(l *Log) bool {
+		return l.Created.Before(before)
+	})
+
+	return nil
+}
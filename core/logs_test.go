@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+func TestMemoryLogStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryLogStore(3)
+	now := time.Now()
+
+	logs := []*Log{
+		{Created: now.Add(-3 * time.Hour), Level: logging.LevelError, Message: "dropped"},
+		{Created: now.Add(-2 * time.Hour), Level: logging.LevelInfo, Message: "a"},
+		{Created: now.Add(-1 * time.Hour), Level: logging.LevelError, Message: "b", Data: map[string]any{"request_id": "abc"}},
+		{Created: now, Level: logging.LevelWarn, Message: "c"},
+	}
+
+	if err := store.SaveLogs(ctx, logs); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		query    LogsQuery
+		expected []string
+	}{
+		{"all", LogsQuery{}, []string{"c", "b", "a"}},
+		{"min level", LogsQuery{Level: logging.LevelWarn}, []string{"c", "b"}},
+		{"since", LogsQuery{Since: now.Add(-90 * time.Minute)}, []string{"c", "b"}},
+		{"request id", LogsQuery{RequestID: "abc"}, []string{"b"}},
+		{"limit", LogsQuery{Limit: 1}, []string{"c"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			found, err := store.FindLogs(ctx, tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			messages := make([]string, len(found))
+			for i, l := range found {
+				messages[i] = l.Message
+
+				if l.ID == "" {
+					t.Fatalf("expected log %q to have an id", l.Message)
+				}
+			}
+
+			if len(messages) != len(tc.expected) {
+				t.Fatalf("expected logs %v, got %v", tc.expected, messages)
+			}
+
+			for i := range messages {
+				if messages[i] != tc.expected[i] {
+					t.Fatalf("expected logs %v, got %v", tc.expected, messages)
+				}
+			}
+		})
+	}
+
+	if err := store.DeleteLogsBefore(ctx, now.Add(-30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if found, _ := store.FindLogs(ctx, LogsQuery{}); len(found) != 1 || found[0].Message != "c" {
+		t.Fatalf("expected only the recent log to be kept, got %d logs", len(found))
+	}
+}
+
+func TestBaseAppLogsPersist(t *testing.T) {
+	store := NewMemoryLogStore(0)
+
+	app := NewBaseApp(BaseAppConfig{
+		LogDisabled: true,
+		LogsPersist: true,
+		LogsLevel:   "warn",
+		LogsStore:   store,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Logger().Info("info message")
+	app.Logger().Error("error message", slog.String("request_id", "abc"), slog.String("token", "secret"))
+
+	if err := app.Terminate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := store.FindLogs(context.Background(), LogsQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 persisted log, got %d", len(logs))
+	}
+
+	l := logs[0]
+
+	if l.Level != logging.LevelError || l.Message != "error message" || l.RequestID() != "abc" {
+		t.Fatalf("expected persisted error log, got %+v", l)
+	}
+
+	if l.Data["token"] != logging.Redacted || l.Data["app"] != "sentinel" {
+		t.Fatalf("expected redacted token and app attributes, got %v", l.Data)
+	}
+}
+
+func TestBaseAppLogsLevelBelowLogLevel(t *testing.T) {
+	store := NewMemoryLogStore(0)
+
+	app := NewBaseApp(BaseAppConfig{
+		LogLevel:    "error",
+		LogSinks:    []logging.SinkConfig{{Writer: io.Discard}},
+		LogsPersist: true,
+		LogsLevel:   "debug",
+		LogsStore:   store,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	app.Logger().Debug("debug message")
+
+	if err := app.Terminate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	logs, err := store.FindLogs(context.Background(), LogsQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(logs) != 1 || logs[0].Message != "debug message" {
+		t.Fatalf("expected the debug log to be persisted below the log level, got %v", logs)
+	}
+}
+
+func TestBaseAppLogsNotPersisted(t *testing.T) {
+	app := NewBaseApp(BaseAppConfig{LogDisabled: true})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	if app.LogStore() != nil {
+		t.Fatal("expected app log store to be nil")
+	}
+}
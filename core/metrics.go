@@ -0,0 +1,78 @@
+package core
+
+import "github.com/dlbarduzzi/sentinel/tools/metrics"
+
+// Metrics groups the Sentinel metrics exposed through the `/metrics` endpoint.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	// HTTP metrics.
+	HTTPRequests        *metrics.Counter
+	HTTPRequestDuration *metrics.Histogram
+	HTTPRequestsFlight  *metrics.Gauge
+
+	// LogsDropped counts the persisted logs dropped because the LogStore
+	// writes could not keep up.
+	LogsDropped *metrics.Counter
+
+	// Domain metrics.
+	ClustersRegistered *metrics.Gauge
+	ClusterSyncs       *metrics.Counter
+	ClusterDrift       *metrics.Gauge
+	RuleGroups         *metrics.Gauge
+}
+
+// Cluster sync results used as the `result` label of Metrics.ClusterSyncs.
+const (
+	SyncResultSuccess = "success"
+	SyncResultFailure = "failure"
+)
+
+func newMetrics() *Metrics {
+	r := metrics.NewRegistry()
+	r.RegisterRuntimeMetrics()
+
+	return &Metrics{
+		Registry: r,
+
+		HTTPRequests: r.NewCounter(
+			"sentinel_http_requests_total",
+			"Total number of http requests by method, route pattern and status.",
+			"method", "route", "status",
+		),
+		HTTPRequestDuration: r.NewHistogram(
+			"sentinel_http_request_duration_seconds",
+			"Http request latencies in seconds by method and route pattern.",
+			metrics.DefaultBuckets,
+			"method", "route",
+		),
+		HTTPRequestsFlight: r.NewGauge(
+			"sentinel_http_requests_in_flight",
+			"Number of http requests currently being served.",
+		),
+
+		LogsDropped: r.NewCounter(
+			"sentinel_logs_dropped_total",
+			"Total number of persisted logs dropped because the log store could not keep up.",
+		),
+
+		ClustersRegistered: r.NewGauge(
+			"sentinel_clusters_registered",
+			"Number of clusters registered in Sentinel.",
+		),
+		ClusterSyncs: r.NewCounter(
+			"sentinel_cluster_syncs_total",
+			"Total number of cluster rule syncs by cluster and result.",
+			"cluster", "result",
+		),
+		ClusterDrift: r.NewGauge(
+			"sentinel_cluster_drift_rules",
+			"Number of rules drifted from the desired state by cluster.",
+			"cluster",
+		),
+		RuleGroups: r.NewGauge(
+			"sentinel_rule_groups",
+			"Number of rule groups managed by Sentinel.",
+		),
+	}
+}
@@ -0,0 +1,17 @@
+package core
+
+import "net/http"
+
+// Router defines the api routes registration available to OnServe hooks,
+// allowing applications built on top of Sentinel to add their own routes.
+type Router interface {
+	// Route registers a handler for the given http method and path. The
+	// path follows the http.ServeMux pattern syntax, e.g. `/api/v1/items/{id}`.
+	// It returns an error if the method or the path is invalid, or if the
+	// route conflicts with an already registered one.
+	Route(method string, path string, handler func(*EventRequest) error) error
+
+	// Use registers middlewares wrapping every route. The first registered
+	// middleware is the outermost one.
+	Use(middlewares ...func(http.Handler) http.Handler)
+}
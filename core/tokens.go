@@ -0,0 +1,396 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Scopes granted to the api tokens.
+const (
+	ScopeRulesRead    = "rules:read"
+	ScopeRulesWrite   = "rules:write"
+	ScopeClustersSync = "clusters:sync"
+
+	// ScopeAdmin grants every other scope and the management of the
+	// api tokens.
+	ScopeAdmin = "admin"
+)
+
+// TokenScopes lists the known token scopes.
+var TokenScopes = []string{ScopeRulesRead, ScopeRulesWrite, ScopeClustersSync, ScopeAdmin}
+
+// TokenPrefix starts every raw api token, making them easy to spot by
+// secret scanners.
+const TokenPrefix = "snt_"
+
+// Token is an api token. Only the hash of the raw token is stored, the raw
+// token is returned once by NewToken.
+type Token struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+	Hash   string   `json:"-"`
+
+	Created time.Time `json:"created"`
+
+	// ExpiresAt is zero for the tokens that never expire.
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+
+	// LastUsedAt is updated at most once a minute, see TokenStore.TouchToken.
+	LastUsedAt time.Time `json:"last_used_at,omitzero"`
+}
+
+// NewToken creates a token with a random id and raw token, returned
+// alongside it. The token expires after ttl, or never when ttl is 0.
+func NewToken(name, owner string, scopes []string, ttl time.Duration) (*Token, string) {
+	raw := TokenPrefix + rand.Text()
+
+	token := &Token{
+		ID:      rand.Text(),
+		Name:    name,
+		Owner:   owner,
+		Scopes:  scopes,
+		Hash:    HashToken(raw),
+		Created: time.Now().UTC(),
+	}
+
+	if ttl > 0 {
+		token.ExpiresAt = token.Created.Add(ttl)
+	}
+
+	return token, raw
+}
+
+// HashToken returns the hex encoded SHA-256 of a raw token. A fast hash
+// is enough since the raw tokens are random and not guessable.
+func HashToken(raw string) string {
+	hash := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(hash[:])
+}
+
+// HasScope reports whether the token grants the scope. The admin scope
+// grants every scope.
+func (t *Token) HasScope(scope string) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+// Expired reports whether the token is expired at the given time.
+func (t *Token) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+type tokenContextKey struct{}
+
+// TokenWithContext returns a copy of ctx carrying the token authenticating
+// the request.
+func TokenWithContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// TokenFromContext returns the token stored in ctx, or nil if none.
+func TokenFromContext(ctx context.Context) *Token {
+	token, _ := ctx.Value(tokenContextKey{}).(*Token)
+	return token
+}
+
+// TokenStore persists the api tokens.
+type TokenStore interface {
+	// SaveToken stores a new token.
+	SaveToken(ctx context.Context, token *Token) error
+
+	// FindTokens returns every token, the most recent first.
+	FindTokens(ctx context.Context) ([]*Token, error)
+
+	// FindTokenByHash returns the token of a raw token hash, or
+	// ErrNotFound.
+	FindTokenByHash(ctx context.Context, hash string) (*Token, error)
+
+	// TouchToken updates the last used time of a token.
+	TouchToken(ctx context.Context, id string, usedAt time.Time) error
+
+	// DeleteToken revokes a token, or returns ErrNotFound.
+	DeleteToken(ctx context.Context, id string) error
+}
+
+// Ensures that the MemoryTokenStore implements the TokenStore interface.
+var _ TokenStore = (*MemoryTokenStore)(nil)
+
+// MemoryTokenStore is a TokenStore keeping the tokens in memory. The
+// tokens are lost when the app restarts.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens []*Token
+}
+
+// NewMemoryTokenStore creates an empty token store.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) SaveToken(_ context.Context, token *Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slices.ContainsFunc(s.tokens, func(t *Token) bool { return t.ID == token.ID }) {
+		return ErrConflict
+	}
+
+	s.tokens = append(s.tokens, cloneToken(token))
+
+	return nil
+}
+
+func (s *MemoryTokenStore) FindTokens(_ context.Context) ([]*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := make([]*Token, 0, len(s.tokens))
+
+	for i := len(s.tokens) - 1; i >= 0; i-- {
+		tokens = append(tokens, cloneToken(s.tokens[i]))
+	}
+
+	return tokens, nil
+}
+
+func (s *MemoryTokenStore) FindTokenByHash(_ context.Context, hash string) (*Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if t.Hash == hash {
+			return cloneToken(t), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+func (s *MemoryTokenStore) TouchToken(_ context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.ID == id {
+			t.LastUsedAt = usedAt
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (s *MemoryTokenStore) DeleteToken(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := len(s.tokens)
+
+	s.tokens = slices.DeleteFunc(s.tokens, func(t *Token) bool {
+		return t.ID == id
+	})
+
+	if len(s.tokens) == n {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// cloneToken copies a token so the stored ones are not mutated by callers.
+func cloneToken(t *Token) *Token {
+	c := *t
+	c.Scopes = slices.Clone(t.Scopes)
+	return &c
+}
+
+// Ensures that the FileTokenStore implements the TokenStore interface.
+var _ TokenStore = (*FileTokenStore)(nil)
+
+// FileTokenStore is a TokenStore persisting the tokens in a json file,
+// e.g. on a volume. The file is read again when another process changes
+// it, such as the `sentinel tokens create` command.
+type FileTokenStore struct {
+	path string
+
+	mu      sync.Mutex
+	modTime time.Time
+	tokens  *MemoryTokenStore
+}
+
+// NewFileTokenStore opens the token store of a json file, created on the
+// first saved token.
+func NewFileTokenStore(path string) (*FileTokenStore, error) {
+	s := &FileTokenStore{path: path, tokens: NewMemoryTokenStore()}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// fileToken is a token as written in the file, including its hash.
+type fileToken struct {
+	*Token
+	Hash string `json:"hash"`
+}
+
+func (s *FileTokenStore) SaveToken(ctx context.Context, token *Token) error {
+	return s.update(func() error { return s.tokens.SaveToken(ctx, token) })
+}
+
+func (s *FileTokenStore) FindTokens(ctx context.Context) ([]*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s.tokens.FindTokens(ctx)
+}
+
+func (s *FileTokenStore) FindTokenByHash(ctx context.Context, hash string) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s.tokens.FindTokenByHash(ctx, hash)
+}
+
+func (s *FileTokenStore) TouchToken(ctx context.Context, id string, usedAt time.Time) error {
+	return s.update(func() error { return s.tokens.TouchToken(ctx, id, usedAt) })
+}
+
+func (s *FileTokenStore) DeleteToken(ctx context.Context, id string) error {
+	return s.update(func() error { return s.tokens.DeleteToken(ctx, id) })
+}
+
+// update applies a change to the tokens read from the file and writes
+// them back.
+func (s *FileTokenStore) update(change func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.load(); err != nil {
+		return err
+	}
+
+	if err := change(); err != nil {
+		return err
+	}
+
+	if err := s.save(); err != nil {
+		// Reads the file again on the next call.
+		s.modTime = time.Time{}
+		return err
+	}
+
+	return nil
+}
+
+// load reads the tokens from the file when it changed since the last
+// read. A missing file has no tokens.
+func (s *FileTokenStore) load() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	var stored []fileToken
+
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse tokens file %q: %w", s.path, err)
+	}
+
+	tokens := make([]*Token, 0, len(stored))
+
+	for _, t := range stored {
+		if t.Token == nil {
+			continue
+		}
+		t.Token.Hash = t.Hash
+		tokens = append(tokens, t.Token)
+	}
+
+	s.tokens.mu.Lock()
+	s.tokens.tokens = tokens
+	s.tokens.mu.Unlock()
+
+	s.modTime = info.ModTime()
+
+	return nil
+}
+
+// save replaces the file with the current tokens. The file is only
+// readable by its owner.
+func (s *FileTokenStore) save() error {
+	s.tokens.mu.RLock()
+	stored := make([]fileToken, 0, len(s.tokens.tokens))
+	for _, t := range s.tokens.tokens {
+		stored = append(stored, fileToken{Token: t, Hash: t.Hash})
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	s.tokens.mu.RUnlock()
+
+	if err != nil {
+		return fmt.Errorf("failed to encode tokens: %w", err)
+	}
+
+	// The file is replaced at once, so a concurrent read never sees a
+	// partial write.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write tokens file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write tokens file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write tokens file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write tokens file: %w", err)
+	}
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	s.modTime = info.ModTime()
+
+	return nil
+}
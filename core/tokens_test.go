@@ -0,0 +1,200 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewToken(t *testing.T) {
+	token, raw := NewToken("ci", "team-a", []string{ScopeRulesRead}, time.Hour)
+
+	if !strings.HasPrefix(raw, TokenPrefix) {
+		t.Fatalf("expected raw token to start with %q, got %q", TokenPrefix, raw)
+	}
+
+	if token.Hash != HashToken(raw) || strings.Contains(token.Hash, raw) {
+		t.Fatalf("expected the token to only store the hash of the raw token, got %q", token.Hash)
+	}
+
+	if token.Expired(time.Now()) || !token.Expired(time.Now().Add(time.Hour)) {
+		t.Fatalf("expected the token to expire after 1h, got %s", token.ExpiresAt)
+	}
+
+	if never, _ := NewToken("ci", "team-a", nil, 0); never.Expired(time.Now().Add(24 * 365 * time.Hour)) {
+		t.Fatal("expected the token without ttl to never expire")
+	}
+}
+
+func TestTokenHasScope(t *testing.T) {
+	testCases := []struct {
+		scopes   []string
+		scope    string
+		expected bool
+	}{
+		{[]string{ScopeRulesRead}, ScopeRulesRead, true},
+		{[]string{ScopeRulesRead}, ScopeRulesWrite, false},
+		{[]string{ScopeAdmin}, ScopeClustersSync, true},
+		{nil, ScopeRulesRead, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.scope, func(t *testing.T) {
+			token := &Token{Scopes: tc.scopes}
+
+			if has := token.HasScope(tc.scope); has != tc.expected {
+				t.Fatalf("expected HasScope(%q) with %v to be %v, got %v", tc.scope, tc.scopes, tc.expected, has)
+			}
+		})
+	}
+}
+
+func TestMemoryTokenStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryTokenStore()
+
+	first, raw := NewToken("first", "team-a", []string{ScopeRulesRead}, 0)
+	second, _ := NewToken("second", "team-b", []string{ScopeAdmin}, 0)
+
+	for _, token := range []*Token{first, second} {
+		if err := store.SaveToken(ctx, token); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.SaveToken(ctx, first); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected duplicated token error to be ErrConflict, got %v", err)
+	}
+
+	tokens, err := store.FindTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 2 || tokens[0].Name != "second" || tokens[1].Name != "first" {
+		t.Fatalf("expected the tokens most recent first, got %v", tokens)
+	}
+
+	found, err := store.FindTokenByHash(ctx, HashToken(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if found.ID != first.ID {
+		t.Fatalf("expected token %q, got %q", first.ID, found.ID)
+	}
+
+	// The stored tokens are not shared with the callers.
+	found.Scopes[0] = ScopeAdmin
+
+	usedAt := time.Now().UTC()
+	if err := store.TouchToken(ctx, first.ID, usedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	found, _ = store.FindTokenByHash(ctx, HashToken(raw))
+	if found.Scopes[0] != ScopeRulesRead {
+		t.Fatalf("expected stored scopes to be unchanged, got %v", found.Scopes)
+	}
+
+	if !found.LastUsedAt.Equal(usedAt) {
+		t.Fatalf("expected last used time to be %s, got %s", usedAt, found.LastUsedAt)
+	}
+
+	if err := store.DeleteToken(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.FindTokenByHash(ctx, HashToken(raw)); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected revoked token error to be ErrNotFound, got %v", err)
+	}
+
+	if err := store.DeleteToken(ctx, first.ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected missing token error to be ErrNotFound, got %v", err)
+	}
+}
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	store, err := NewFileTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, raw := NewToken("ci", "team-a", []string{ScopeRulesRead}, time.Hour)
+
+	if err := store.SaveToken(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("expected the tokens file to only be readable by its owner, got %v (%v)", info.Mode(), err)
+	}
+
+	// Another process, e.g. the tokens command, adds a token.
+	other, err := NewFileTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added, addedRaw := NewToken("agent", "team-b", []string{ScopeClustersSync}, 0)
+
+	if err := other.SaveToken(ctx, added); err != nil {
+		t.Fatal(err)
+	}
+
+	// Forces a different modification time on coarse grained file systems.
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := store.FindTokenByHash(ctx, HashToken(addedRaw))
+	if err != nil {
+		t.Fatalf("expected the token added by another store to be found, got %v", err)
+	}
+
+	if found.ID != added.ID {
+		t.Fatalf("expected token %q, got %q", added.ID, found.ID)
+	}
+
+	if err := store.DeleteToken(ctx, added.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFileTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := reopened.FindTokens(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tokens) != 1 || tokens[0].ID != token.ID || !tokens[0].ExpiresAt.Equal(token.ExpiresAt) {
+		t.Fatalf("expected the persisted token %q, got %v", token.ID, tokens)
+	}
+
+	if _, err := reopened.FindTokenByHash(ctx, HashToken(raw)); err != nil {
+		t.Fatalf("expected the persisted token hash to be found, got %v", err)
+	}
+}
+
+func TestFileTokenStoreInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileTokenStore(path); err == nil {
+		t.Fatal("expected an invalid tokens file error")
+	}
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dlbarduzzi/sentinel/core"
+
+// startStoreSpan starts a client span for a store call, e.g.
+// "TokenStore.SaveToken", as a child of the span of ctx.
+func startStoreSpan(ctx context.Context, tracer trace.Tracer, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient))
+}
+
+// endStoreSpan records err on the span, ErrNotFound excepted, and ends it.
+func endStoreSpan(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Ensures that the tracedTokenStore implements the TokenStore interface.
+var _ TokenStore = (*tracedTokenStore)(nil)
+
+// tracedTokenStore wraps a TokenStore with a span around every call.
+type tracedTokenStore struct {
+	store  TokenStore
+	tracer trace.Tracer
+}
+
+func (s *tracedTokenStore) SaveToken(ctx context.Context, token *Token) (err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "TokenStore.SaveToken")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.SaveToken(ctx, token)
+}
+
+func (s *tracedTokenStore) FindTokens(ctx context.Context) (_ []*Token, err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "TokenStore.FindTokens")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.FindTokens(ctx)
+}
+
+func (s *tracedTokenStore) FindTokenByHash(ctx context.Context, hash string) (_ *Token, err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "TokenStore.FindTokenByHash")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.FindTokenByHash(ctx, hash)
+}
+
+func (s *tracedTokenStore) TouchToken(ctx context.Context, id string, usedAt time.Time) (err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "TokenStore.TouchToken")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.TouchToken(ctx, id, usedAt)
+}
+
+func (s *tracedTokenStore) DeleteToken(ctx context.Context, id string) (err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "TokenStore.DeleteToken")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.DeleteToken(ctx, id)
+}
+
+// Ensures that the tracedLogStore implements the LogStore interface.
+var _ LogStore = (*tracedLogStore)(nil)
+
+// tracedLogStore wraps a LogStore with a span around every call.
+type tracedLogStore struct {
+	store  LogStore
+	tracer trace.Tracer
+}
+
+func (s *tracedLogStore) SaveLogs(ctx context.Context, logs []*Log) (err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "LogStore.SaveLogs")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.SaveLogs(ctx, logs)
+}
+
+func (s *tracedLogStore) FindLogs(ctx context.Context, query LogsQuery) (_ []*Log, err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "LogStore.FindLogs")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.FindLogs(ctx, query)
+}
+
+func (s *tracedLogStore) DeleteLogsBefore(ctx context.Context, before time.Time) (err error) {
+	ctx, span := startStoreSpan(ctx, s.tracer, "LogStore.DeleteLogsBefore")
+	defer func() { endStoreSpan(span, err) }()
+
+	return s.store.DeleteLogsBefore(ctx, before)
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/tools/tracing"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBaseAppStoreSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+
+	provider, err := tracing.NewProvider(tracing.Config{SpanExporter: exporter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := NewBaseApp(BaseAppConfig{
+		LogDisabled:    true,
+		LogsPersist:    true,
+		TracerProvider: provider,
+	})
+
+	if err := app.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+
+	token, _ := NewToken("test", "team-a", []string{ScopeRulesRead}, 0)
+
+	if err := app.TokenStore().SaveToken(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.TokenStore().FindTokenByHash(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected missing token error to be ErrNotFound, got %v", err)
+	}
+
+	if err := app.TokenStore().SaveToken(ctx, token); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected duplicated token error to be ErrConflict, got %v", err)
+	}
+
+	if _, err := app.LogStore().FindLogs(ctx, LogsQuery{}); err != nil {
+		t.Fatal(err)
+	}
+
+	parent.End()
+
+	spans := exporter.GetSpans()
+
+	scenarios := []struct {
+		name   string
+		status codes.Code
+	}{
+		{"TokenStore.SaveToken", codes.Unset},
+		{"TokenStore.FindTokenByHash", codes.Unset},
+		{"TokenStore.SaveToken", codes.Error},
+		{"LogStore.FindLogs", codes.Unset},
+		{"parent", codes.Unset},
+	}
+
+	if len(spans) != len(scenarios) {
+		t.Fatalf("expected %d spans, got %d", len(scenarios), len(spans))
+	}
+
+	for i, s := range scenarios {
+		span := spans[i]
+
+		if span.Name != s.name {
+			t.Fatalf("expected span %d to be %q, got %q", i, s.name, span.Name)
+		}
+
+		if span.Status.Code != s.status {
+			t.Fatalf("expected span %q status to be %v, got %v", s.name, s.status, span.Status.Code)
+		}
+
+		if s.name != "parent" && span.Parent.SpanID() != parent.SpanContext().SpanID() {
+			t.Fatalf("expected span %q to be a child of the parent span", s.name)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+)
+
+// ReloadConfig reads the config file and the .env file again and applies
+// the settings that are safe to change at runtime, such as the log level
+// and format, through the OnConfigReload hook. Changes to the other
+// settings, e.g. the server port, are logged and ignored until restart.
+func (s *Sentinel) ReloadConfig() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.configMu.RLock()
+	r := s.configRegistry
+	current := s.config
+	s.configMu.RUnlock()
+
+	if r == nil {
+		return errors.New("config not loaded")
+	}
+
+	if err := r.Reload(); err != nil {
+		return fmt.Errorf("failed to reload config - %w", err)
+	}
+
+	config, sources, err := resolveConfig(s.defaults, r)
+	if err != nil {
+		return err
+	}
+
+	next := current
+	event := &core.ConfigReloadEvent{App: s}
+
+	for _, field := range configFields {
+		value := field.get(&config)
+		if value == field.get(&current) {
+			continue
+		}
+
+		if !field.reloadable {
+			event.Rejected = append(event.Rejected, field.key)
+			continue
+		}
+
+		// The value was already parsed and validated by resolveConfig.
+		_ = field.set(&next, value)
+		event.Changed = append(event.Changed, field.key)
+	}
+
+	for _, key := range event.Rejected {
+		s.Logger().Warn("config change requires a restart", slog.String("key", key))
+	}
+
+	if len(event.Changed) == 0 {
+		return nil
+	}
+
+	return s.OnConfigReload().Trigger(event, func(e *core.ConfigReloadEvent) error {
+		s.configMu.Lock()
+
+		s.config = next
+		s.configSources = maps.Clone(s.configSources)
+		for _, key := range e.Changed {
+			s.configSources[key] = sources[key]
+		}
+
+		s.configMu.Unlock()
+
+		if next.LogLevel != current.LogLevel {
+			s.baseApp.SetLogLevel(logging.LogLevel(next.LogLevel))
+
+			if state := s.LogLevels().Level(""); !state.ExpiresAt.IsZero() {
+				s.Logger().Info(
+					"log level override kept, the reloaded level applies when it expires",
+					slog.String("level", string(state.Level)),
+					slog.Time("expires_at", state.ExpiresAt),
+				)
+			}
+		}
+
+		if next.LogFormat != current.LogFormat {
+			if err := s.baseApp.SetLogFormat(logging.LogFormat(next.LogFormat)); err != nil {
+				return err
+			}
+		}
+
+		s.Logger().Info("config reloaded", slog.Any("changed", e.Changed))
+
+		return e.Next()
+	})
+}
+
+// watchConfig reloads the config when the config file or the .env file
+// changes, until ctx is done.
+func (s *Sentinel) watchConfig(ctx context.Context) error {
+	reload := func() {
+		if err := s.ReloadConfig(); err != nil {
+			s.Logger().Error("failed to reload config", slog.String("error", err.Error()))
+		}
+	}
+
+	s.configMu.RLock()
+	r := s.configRegistry
+	s.configMu.RUnlock()
+
+	return r.Watch(ctx, reload)
+}
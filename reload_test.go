@@ -0,0 +1,187 @@
+package sentinel
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
+	"github.com/dlbarduzzi/sentinel/tools/registry"
+)
+
+func newReloadApp(t *testing.T, content string) (*Sentinel, string) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfigFile(t, file, content)
+
+	app := NewWithConfig(DefaultConfig())
+	app.configFile = file
+
+	if err := app.loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	return app, file
+}
+
+func writeConfigFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	app, file := newReloadApp(t, "log_level: info\nserver_port: 9000\n")
+
+	var event *core.ConfigReloadEvent
+
+	app.OnConfigReload().BindFunc(func(e *core.ConfigReloadEvent) error {
+		event = e
+		return e.Next()
+	})
+
+	writeConfigFile(t, file, "log_level: debug\nserver_port: 9100\n")
+
+	if err := app.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if event == nil {
+		t.Fatal("expected OnConfigReload to be triggered")
+	}
+
+	if !slices.Equal(event.Changed, []string{"LOG_LEVEL"}) {
+		t.Fatalf("expected changed keys to be [LOG_LEVEL], got %v", event.Changed)
+	}
+
+	if !slices.Equal(event.Rejected, []string{"SERVER_PORT"}) {
+		t.Fatalf("expected rejected keys to be [SERVER_PORT], got %v", event.Rejected)
+	}
+
+	config := app.Config()
+
+	if config.LogLevel != "debug" {
+		t.Fatalf("expected log level to be %q, got %q", "debug", config.LogLevel)
+	}
+
+	if config.ServerPort != 9000 {
+		t.Fatalf("expected server port to be 9000, got %d", config.ServerPort)
+	}
+
+	if source := app.configSources["LOG_LEVEL"]; source != registry.SourceFile {
+		t.Fatalf("expected log level source to be %q, got %q", registry.SourceFile, source)
+	}
+
+	if level := app.LogLevels().Level("").Level; level != logging.LevelDebug {
+		t.Fatalf("expected logger level to be %q, got %q", logging.LevelDebug, level)
+	}
+}
+
+func TestReloadConfigKeepsLogLevelOverride(t *testing.T) {
+	app, file := newReloadApp(t, "log_level: info\n")
+
+	// An admin raised the level for a while.
+	app.LogLevels().Set("", logging.LevelDebug, 50*time.Millisecond)
+
+	writeConfigFile(t, file, "log_level: warn\n")
+
+	if err := app.ReloadConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	if level := app.LogLevels().Level("").Level; level != logging.LevelDebug {
+		t.Fatalf("expected the override to be kept, got %q", level)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for app.LogLevels().Level("").Level != logging.LevelWarn {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the level to revert to the reloaded %q, got %q", logging.LevelWarn, app.LogLevels().Level("").Level)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestReloadConfigRejected(t *testing.T) {
+	errTest := errors.New("test")
+
+	testCases := []struct {
+		name    string
+		content string
+		handler func(e *core.ConfigReloadEvent) error
+	}{
+		{
+			name:    "invalid value",
+			content: "log_level: verbose\n",
+		},
+		{
+			name:    "hook error",
+			content: "log_level: debug\n",
+			handler: func(*core.ConfigReloadEvent) error { return errTest },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			app, file := newReloadApp(t, "log_level: info\n")
+
+			if tc.handler != nil {
+				app.OnConfigReload().BindFunc(tc.handler)
+			}
+
+			writeConfigFile(t, file, tc.content)
+
+			if err := app.ReloadConfig(); err == nil {
+				t.Fatal("expected reload error, got nil")
+			}
+
+			if level := app.Config().LogLevel; level != "info" {
+				t.Fatalf("expected log level to be %q, got %q", "info", level)
+			}
+		})
+	}
+}
+
+func TestReloadConfigNotLoaded(t *testing.T) {
+	if err := NewWithConfig(DefaultConfig()).ReloadConfig(); err == nil {
+		t.Fatal("expected reload error, got nil")
+	}
+}
+
+func TestWatchConfig(t *testing.T) {
+	app, file := newReloadApp(t, "log_level: info\n")
+
+	reloaded := make(chan []string, 1)
+
+	app.OnConfigReload().BindFunc(func(e *core.ConfigReloadEvent) error {
+		reloaded <- e.Changed
+		return e.Next()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := app.watchConfig(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	writeConfigFile(t, file, "log_format: text\n")
+
+	select {
+	case changed := <-reloaded:
+		if !slices.Equal(changed, []string{"LOG_FORMAT"}) {
+			t.Fatalf("expected changed keys to be [LOG_FORMAT], got %v", changed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected config file change to be reloaded")
+	}
+}
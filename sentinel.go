@@ -1,115 +1,276 @@
 package sentinel
 
 import (
+	"context"
 	"fmt"
-	"os"
-	"time"
+	"log/slog"
+	"net/http"
+	"sync"
 
 	"github.com/dlbarduzzi/sentinel/apis"
 	"github.com/dlbarduzzi/sentinel/core"
+	"github.com/dlbarduzzi/sentinel/tools/logging"
 	"github.com/dlbarduzzi/sentinel/tools/registry"
+	"github.com/dlbarduzzi/sentinel/tools/tracing"
+	"github.com/spf13/cobra"
 )
 
+// Version of the Sentinel binary, set at build time with
+// `-ldflags "-X github.com/dlbarduzzi/sentinel.Version=x.y.z"`.
+var Version = "(untracked)"
+
 // Ensures that the Sentinel implements the App interface.
 var _ core.App = (*Sentinel)(nil)
 
 type Sentinel struct {
 	core.App
 
-	// Logger configs
-	logLevel  string
-	logFormat string
+	// RootCmd is the main console command. Applications embedding
+	// Sentinel can register their own commands on it before Execute.
+	RootCmd *cobra.Command
 
-	// Server configs.
-	serverPort         int
-	serverIdleTimeout  time.Duration
-	serverReadTimeout  time.Duration
-	serverWriteTimeout time.Duration
-}
+	baseApp *core.BaseApp
+
+	// defaults holds the config passed to NewWithConfig and config the
+	// effective config resolved by loadConfig and ReloadConfig.
+	defaults       Config
+	configMu       sync.RWMutex
+	config         Config
+	configSources  map[string]registry.Source
+	configRegistry *registry.Layered
+
+	// reloadMu serializes the config reloads.
+	reloadMu sync.Mutex
 
-// Config is the Sentinel initialization config struct.
-type Config struct {
-	// Logger configs
-	LogLevel  string
-	LogFormat string
-
-	// Server configs.
-	ServerPort         int
-	ServerIdleTimeout  time.Duration
-	ServerReadTimeout  time.Duration
-	ServerWriteTimeout time.Duration
+	// configFile and flags are set by the command line flags.
+	configFile string
+	flags      map[string]string
+
+	// legacyTimeouts lists the timeouts passed to NewWithConfig as a
+	// number of seconds, logged as deprecated by Start.
+	legacyTimeouts []string
 }
 
 func New() *Sentinel {
-	return NewWithConfig(Config{
-		LogLevel:           "info",
-		LogFormat:          "json",
-		ServerPort:         8090,
-		ServerIdleTimeout:  5,
-		ServerReadTimeout:  5,
-		ServerWriteTimeout: 5,
-	})
+	return NewWithConfig(DefaultConfig())
 }
 
+// NewWithConfig creates a Sentinel app with the given config defaults. The
+// zero settings take their DefaultConfig value.
 func NewWithConfig(config Config) *Sentinel {
+	config.fillDefaults()
+
+	legacyTimeouts := config.upgradeLegacyTimeouts()
+
 	s := &Sentinel{
-		logLevel:           config.LogLevel,
-		logFormat:          config.LogFormat,
-		serverPort:         config.ServerPort,
-		serverIdleTimeout:  config.ServerIdleTimeout,
-		serverReadTimeout:  config.ServerReadTimeout,
-		serverWriteTimeout: config.ServerWriteTimeout,
+		defaults:       config,
+		config:         config,
+		flags:          make(map[string]string),
+		legacyTimeouts: legacyTimeouts,
 	}
 
-	s.parseConfig(&config)
-
-	s.App = core.NewBaseApp(core.BaseAppConfig{
-		LogLevel:  s.logLevel,
-		LogFormat: s.logFormat,
+	s.baseApp = core.NewBaseApp(core.BaseAppConfig{
+		LogLevel:  config.LogLevel,
+		LogFormat: config.LogFormat,
 	})
 
+	s.App = s.baseApp
+
+	s.RootCmd = &cobra.Command{
+		Use:     "sentinel",
+		Short:   "Sentinel CLI",
+		Long:    "A centralized control plane for managing and synchronizing Prometheus alerts across multiple clusters.",
+		Version: Version,
+
+		// Errors are returned by Execute and reported by the caller.
+		SilenceErrors: true,
+		SilenceUsage:  true,
+
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd: true,
+		},
+	}
+
+	s.RootCmd.PersistentFlags().StringVar(
+		&s.configFile,
+		"config",
+		"",
+		"yaml or toml config file path (defaults to "+envConfigFile+")",
+	)
+
+	s.registerDefaultCommands()
+
 	return s
 }
 
-func (s *Sentinel) Start() error {
-	if err := s.Bootstrap(); err != nil {
+// Execute runs the command matching the process arguments. The server
+// started by the serve command runs until the process exits, use
+// ExecuteContext to shut it down gracefully.
+func (s *Sentinel) Execute() error {
+	return s.ExecuteContext(context.Background())
+}
+
+// ExecuteContext runs the command matching the process arguments. The
+// server started by the serve command shuts down when ctx is done.
+func (s *Sentinel) ExecuteContext(ctx context.Context) error {
+	return s.RootCmd.ExecuteContext(ctx)
+}
+
+// Start resolves the config, bootstraps the app and starts the http
+// server. It blocks until ctx is done and the server is shut down.
+func (s *Sentinel) Start(ctx context.Context) error {
+	if err := s.loadConfig(); err != nil {
 		return err
 	}
 
-	return apis.Serve(s.App, apis.ServeConfig{
-		Port:         s.serverPort,
-		IdleTimeout:  time.Second * s.serverIdleTimeout,
-		ReadTimeout:  time.Second * s.serverReadTimeout,
-		WriteTimeout: time.Second * s.serverWriteTimeout,
-	})
-}
+	// The config is read once, ReloadConfig changes it concurrently once
+	// the config files are watched.
+	cfg := s.Config()
 
-func (s *Sentinel) parseConfig(config *Config) {
-	// Set logger config defaults.
-	s.logLevel = config.LogLevel
-	s.logFormat = config.LogFormat
+	s.baseApp.Config().LogLevel = cfg.LogLevel
+	s.baseApp.Config().LogFormat = cfg.LogFormat
+	s.baseApp.Config().LogOutput = cfg.LogOutput
+	s.baseApp.Config().LogRotation = logging.RotationConfig{
+		MaxSizeMB:  cfg.LogFileMaxSizeMB,
+		MaxAgeDays: cfg.LogFileMaxAgeDays,
+		MaxBackups: cfg.LogFileMaxBackups,
+		Compress:   cfg.LogFileCompress,
+	}
+	s.baseApp.Config().LogSinks = cfg.LogSinks
+	s.baseApp.Config().LogRedact = cfg.logRedactConfig()
+	s.baseApp.Config().LogsPersist = cfg.LogsPersist
+	s.baseApp.Config().LogsLevel = cfg.LogsLevel
+	s.baseApp.Config().LogsMaxDays = cfg.LogsMaxDays
+	s.baseApp.Config().LogsStore = cfg.LogsStore
+	s.baseApp.Config().AdminTokenHash = cfg.AdminTokenHash
 
-	// Set server config defaults.
-	s.serverPort = config.ServerPort
-	s.serverIdleTimeout = config.ServerIdleTimeout
-	s.serverReadTimeout = config.ServerReadTimeout
-	s.serverWriteTimeout = config.ServerWriteTimeout
+	tokensStore, err := cfg.tokensStore()
+	if err != nil {
+		return err
+	}
 
-	r, err := registry.NewWithConfig(registry.Config{
-		EnvPrefix: "SENTINEL",
+	if tokensStore != nil {
+		s.baseApp.Config().TokensStore = tokensStore
+	}
+
+	tracerProvider, err := tracing.NewProvider(tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		SampleRatio: cfg.TracingSampleRatio,
 	})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "[error] fail to load registry - %s\n", err)
-		return
+		return fmt.Errorf("failed to create tracer provider - %w", err)
 	}
 
-	// Read logger env variables.
-	s.logLevel = r.GetString("LOG_LEVEL")
-	s.logFormat = r.GetString("LOG_FORMAT")
+	s.baseApp.Config().TracerProvider = tracerProvider
+
+	s.OnTerminate().BindFunc(func(e *core.TerminateEvent) error {
+		// Exports the spans still buffered.
+		e.Go("tracing", tracerProvider.Shutdown)
+		return e.Next()
+	})
 
-	// Read server env variables.
-	s.serverPort = r.GetInt("SERVER_PORT")
-	s.serverIdleTimeout = r.GetDuration("SERVER_IDLE_TIMEOUT_SECS")
-	s.serverReadTimeout = r.GetDuration("SERVER_READ_TIMEOUT_SECS")
-	s.serverWriteTimeout = r.GetDuration("SERVER_WRITE_TIMEOUT_SECS")
+	if err := s.Bootstrap(); err != nil {
+		return err
+	}
+
+	s.Logger().Info("config loaded", s.configSourcesAttr())
+
+	if tokensStore == nil {
+		s.Logger().Warn("api tokens are kept in memory and lost on restart, set TOKENS_FILE to persist them")
+	}
+
+	for _, key := range s.legacyTimeouts {
+		s.Logger().Warn(
+			"timeout set as a number of seconds is deprecated, use a time.Duration",
+			slog.String("key", key),
+		)
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := s.watchConfig(watchCtx); err != nil {
+		// Reloading with ReloadConfig, e.g. on SIGHUP, keeps working.
+		s.Logger().Warn("failed to watch config files", slog.String("error", err.Error()))
+	}
+
+	var listeners []apis.ListenerConfig
+
+	if cfg.AdminAddr != "" {
+		listeners = append(listeners, apis.ListenerConfig{Addr: cfg.AdminAddr, Admin: true})
+	}
+
+	if cfg.ServerSocket != "" {
+		listeners = append(listeners, apis.ListenerConfig{Name: "socket", Addr: "unix:" + cfg.ServerSocket})
+	}
+
+	return apis.Serve(ctx, s.App, apis.ServeConfig{
+		Port:         cfg.ServerPort,
+		IdleTimeout:  cfg.ServerIdleTimeout,
+		ReadTimeout:  cfg.ServerReadTimeout,
+		WriteTimeout: cfg.ServerWriteTimeout,
+
+		ReadHeaderTimeout:  cfg.ServerReadHeaderTimeout,
+		MaxHeaderBytes:     cfg.ServerMaxHeaderBytes,
+		H2C:                cfg.ServerH2C,
+		KeepAlivesDisabled: cfg.ServerKeepAlivesDisabled,
+		MaxConnections:     cfg.ServerMaxConnections,
+
+		ShutdownGracePeriod: cfg.ServerShutdownGracePeriod,
+		PreShutdownDelay:    cfg.ServerPreShutdownDelay,
+		TerminateTimeout:    cfg.ServerTerminateTimeout,
+
+		AccessLog: apis.AccessLogConfig{
+			Disabled:          cfg.AccessLogDisabled,
+			SkipHealthChecks:  cfg.AccessLogSkipHealth,
+			SuccessSampleRate: cfg.AccessLogSampleRate,
+		},
+		MetricsDisabled: cfg.MetricsDisabled,
+		MetricsPort:     cfg.MetricsPort,
+		TLS: apis.TLSConfig{
+			CertFile:          cfg.TLSCertFile,
+			KeyFile:           cfg.TLSKeyFile,
+			MinVersion:        cfg.TLSMinVersion,
+			CipherSuites:      cfg.tlsCipherSuites(),
+			ClientCAFile:      cfg.TLSClientCAFile,
+			RequireClientCert: cfg.TLSClientCertRequired,
+		},
+		Listeners: listeners,
+	})
+}
+
+// Config returns the effective config, including the changes applied at
+// runtime by ReloadConfig.
+func (s *Sentinel) Config() Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+
+	return s.config
+}
+
+// Route registers a custom api route served alongside the Sentinel routes.
+// It must be called before Start. The path follows the http.ServeMux
+// pattern syntax, e.g. `/api/v1/items/{id}`. Start fails if the route is
+// invalid or conflicts with another one.
+func (s *Sentinel) Route(method string, path string, handler func(*core.EventRequest) error) {
+	s.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		if err := e.Router.Route(method, path, handler); err != nil {
+			return err
+		}
+		return e.Next()
+	})
+}
+
+// Use registers http middlewares wrapping every api route, including the
+// Sentinel ones, on the public and the admin routers. It must be called
+// before Start.
+func (s *Sentinel) Use(middlewares ...func(http.Handler) http.Handler) {
+	s.OnServe().BindFunc(func(e *core.ServeEvent) error {
+		e.Router.Use(middlewares...)
+		if e.AdminRouter != nil {
+			e.AdminRouter.Use(middlewares...)
+		}
+		return e.Next()
+	})
 }
@@ -1,6 +1,14 @@
 package sentinel
 
-import "testing"
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dlbarduzzi/sentinel/core"
+)
 
 func TestNew(t *testing.T) {
 	app := New()
@@ -14,6 +22,26 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNewWithConfigLegacyTimeouts(t *testing.T) {
+	config := DefaultConfig()
+	config.ServerReadTimeout = 5
+	config.ServerWriteTimeout = 10 * time.Second
+
+	app := NewWithConfig(config)
+
+	if timeout := app.Config().ServerReadTimeout; timeout != 5*time.Second {
+		t.Fatalf("expected a timeout of 5 to be read as 5s, got %s", timeout)
+	}
+
+	if timeout := app.Config().ServerWriteTimeout; timeout != 10*time.Second {
+		t.Fatalf("expected the write timeout to be unchanged, got %s", timeout)
+	}
+
+	if !slices.Equal(app.legacyTimeouts, []string{"SERVER_READ_TIMEOUT"}) {
+		t.Fatalf("expected only the read timeout to be deprecated, got %v", app.legacyTimeouts)
+	}
+}
+
 func TestNewWithConfig(t *testing.T) {
 	app := NewWithConfig(Config{})
 
@@ -25,3 +53,54 @@ func TestNewWithConfig(t *testing.T) {
 		t.Fatal("expected app.App to be initialized, got nil")
 	}
 }
+
+type testRouter struct {
+	routes      []string
+	middlewares int
+}
+
+func (r *testRouter) Route(method string, path string, _ func(*core.EventRequest) error) error {
+	if method == "" {
+		return errors.New("invalid route method")
+	}
+	r.routes = append(r.routes, method+" "+path)
+	return nil
+}
+
+func (r *testRouter) Use(middlewares ...func(http.Handler) http.Handler) {
+	r.middlewares += len(middlewares)
+}
+
+func TestRouteAndUse(t *testing.T) {
+	app := NewWithConfig(Config{})
+
+	app.Route(http.MethodGet, "/api/v1/custom", func(e *core.EventRequest) error {
+		return e.Status(http.StatusOK)
+	})
+
+	app.Use(func(next http.Handler) http.Handler { return next })
+
+	router, adminRouter := &testRouter{}, &testRouter{}
+
+	err := app.OnServe().Trigger(&core.ServeEvent{App: app, Router: router, AdminRouter: adminRouter})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(router.routes) != 1 || router.routes[0] != "GET /api/v1/custom" {
+		t.Fatalf("expected custom route to be registered, got %v", router.routes)
+	}
+
+	if router.middlewares != 1 || adminRouter.middlewares != 1 {
+		t.Fatalf("expected 1 middleware to be registered on both routers, got %d and %d", router.middlewares, adminRouter.middlewares)
+	}
+
+	app.Route("", "/api/v1/invalid", func(e *core.EventRequest) error {
+		return e.Status(http.StatusOK)
+	})
+
+	err = app.OnServe().Trigger(&core.ServeEvent{App: app, Router: &testRouter{}})
+	if err == nil {
+		t.Fatal("expected an invalid route to fail the serve event")
+	}
+}
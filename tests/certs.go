@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCert is a certificate and its key generated for the tls tests.
+type TestCert struct {
+	Cert *x509.Certificate
+	Key  *ecdsa.PrivateKey
+	Pair tls.Certificate
+}
+
+// NewTestCert creates a certificate valid for an hour, signed by parent
+// or self-signed when parent is nil.
+func NewTestCert(t testing.TB, template *x509.Certificate, parent *TestCert) *TestCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.Cert, parent.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &TestCert{
+		Cert: cert,
+		Key:  key,
+		Pair: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key},
+	}
+}
+
+// WriteFiles writes the PEM encoded certificate and key to `name.pem` and
+// `name-key.pem` in dir, and returns their paths.
+func (c *TestCert) WriteFiles(t testing.TB, dir, name string) (string, string) {
+	t.Helper()
+
+	keyDer, err := x509.MarshalECPrivateKey(c.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile := filepath.Join(dir, name+".pem")
+	keyFile := filepath.Join(dir, name+"-key.pem")
+
+	certPem := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Cert.Raw})
+	keyPem := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+
+	if err := os.WriteFile(certFile, certPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, keyPem, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return certFile, keyFile
+}
@@ -0,0 +1,222 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCheckInterval is the minimum delay between two checks of the
+// certificate files for changes.
+const DefaultCheckInterval = 10 * time.Second
+
+// TLS versions accepted by ParseVersion. TLS 1.0 and 1.1 are deprecated
+// by RFC 8996 and rejected.
+var versions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion parses a TLS version, `1.2` or `1.3`.
+func ParseVersion(value string) (uint16, error) {
+	version, ok := versions[strings.TrimPrefix(strings.TrimSpace(value), "TLS")]
+	if !ok {
+		return 0, fmt.Errorf("unknown or insecure tls version %q", value)
+	}
+	return version, nil
+}
+
+// CheckHTTP2CipherSuites returns an error when the cipher suites miss the
+// AES_128_GCM_SHA256 suite required by HTTP/2 (RFC 9113), which makes
+// http.Server.ServeTLS fail. The cipher suites are ignored from TLS 1.3.
+func CheckHTTP2CipherSuites(minVersion uint16, suites []uint16) error {
+	if len(suites) == 0 || minVersion >= tls.VersionTLS13 {
+		return nil
+	}
+
+	for _, suite := range suites {
+		if suite == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || suite == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+			return nil
+		}
+	}
+
+	return errors.New("http/2 requires TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 or TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256")
+}
+
+// ParseCipherSuites parses cipher suite names such as
+// `TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256`. Only the secure cipher suites
+// of the crypto/tls package are accepted.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	suites := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		suites[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+
+	for _, name := range names {
+		id, ok := suites[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// LoadCertPool reads the PEM encoded certificates of a CA bundle file.
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %q", file)
+	}
+
+	return pool, nil
+}
+
+// Reloader serves a certificate key pair and an optional client CA bundle,
+// reloading them when their files change, e.g. when the certificates are
+// rotated by cert-manager. The previous files keep being served when the
+// new ones fail to load.
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	// CheckInterval is the minimum delay between two checks of the files
+	// for changes. Defaults to DefaultCheckInterval.
+	CheckInterval time.Duration
+
+	// OnError is called when the changed files fail to load.
+	OnError func(err error)
+
+	mu      sync.Mutex
+	checked time.Time
+	stamp   string
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+// NewReloader loads the certificate key pair and the CA bundle, when
+// caFile is not empty.
+func NewReloader(certFile, keyFile, caFile string) (*Reloader, error) {
+	r := &Reloader{
+		certFile:      certFile,
+		keyFile:       keyFile,
+		caFile:        caFile,
+		CheckInterval: DefaultCheckInterval,
+	}
+
+	stamp, err := r.fileStamp()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.load(stamp); err != nil {
+		return nil, err
+	}
+
+	r.checked = time.Now()
+
+	return r, nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reload()
+
+	return r.cert, nil
+}
+
+// ClientCAs returns the current CA bundle, or nil when there is none.
+func (r *Reloader) ClientCAs() *x509.CertPool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reload()
+
+	return r.pool
+}
+
+// reload loads the files again if they changed since the last check.
+// It must be called with the lock held.
+func (r *Reloader) reload() {
+	if time.Since(r.checked) < r.CheckInterval {
+		return
+	}
+
+	r.checked = time.Now()
+
+	stamp, err := r.fileStamp()
+	if err == nil && stamp == r.stamp {
+		return
+	}
+
+	if err == nil {
+		err = r.load(stamp)
+	}
+
+	if err != nil && r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+func (r *Reloader) load(stamp string) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate - %w", err)
+	}
+
+	var pool *x509.CertPool
+
+	if r.caFile != "" {
+		pool, err = LoadCertPool(r.caFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client ca bundle - %w", err)
+		}
+	}
+
+	r.cert = &cert
+	r.pool = pool
+	r.stamp = stamp
+
+	return nil
+}
+
+// fileStamp identifies the current version of the files by their size
+// and modification time. Stat follows the symlinks swapped by Kubernetes
+// secret volumes.
+func (r *Reloader) fileStamp() (string, error) {
+	var stamp strings.Builder
+	var errs []error
+
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		fmt.Fprintf(&stamp, "%d:%d;", info.Size(), info.ModTime().UnixNano())
+	}
+
+	return stamp.String(), errors.Join(errs...)
+}
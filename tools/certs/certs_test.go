@@ -0,0 +1,177 @@
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dlbarduzzi/sentinel/tests"
+)
+
+// selfSigned creates a self-signed CA certificate for the given common name.
+func selfSigned(t *testing.T, name string) *tests.TestCert {
+	return tests.NewTestCert(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return leaf.Subject.CommonName
+}
+
+func TestParseVersion(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint16
+		hasError bool
+	}{
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"TLS1.3", tls.VersionTLS13, false},
+		{"", 0, true},
+		{"1.0", 0, true},
+		{"1.1", 0, true},
+		{"2.0", 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			version, err := ParseVersion(tc.value)
+
+			hasError := err != nil
+			if hasError != tc.hasError {
+				t.Fatalf("expected hasError to be %v, got %v (%v)", tc.hasError, hasError, err)
+			}
+
+			if version != tc.expected {
+				t.Fatalf("expected version to be %d, got %d", tc.expected, version)
+			}
+		})
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", " TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(suites) != 2 || suites[0] != tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 {
+		t.Fatalf("expected 2 parsed cipher suites, got %v", suites)
+	}
+
+	for _, name := range []string{"TLS_RSA_WITH_RC4_128_SHA", "unknown"} {
+		if _, err := ParseCipherSuites([]string{name}); err == nil {
+			t.Fatalf("expected %q to be rejected", name)
+		}
+	}
+}
+
+func TestCheckHTTP2CipherSuites(t *testing.T) {
+	testCases := []struct {
+		name       string
+		minVersion uint16
+		suites     []uint16
+		hasError   bool
+	}{
+		{"default suites", tls.VersionTLS12, nil, false},
+		{"rsa suite", tls.VersionTLS12, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, false},
+		{"ecdsa suite", tls.VersionTLS12, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, false},
+		{"missing suite", tls.VersionTLS12, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, true},
+		{"tls 1.3 only", tls.VersionTLS13, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckHTTP2CipherSuites(tc.minVersion, tc.suites)
+
+			if hasError := err != nil; hasError != tc.hasError {
+				t.Fatalf("expected hasError to be %v, got %v (%v)", tc.hasError, hasError, err)
+			}
+		})
+	}
+}
+
+func TestLoadCertPool(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := selfSigned(t, "ca").WriteFiles(t, dir, "cert")
+
+	if _, err := LoadCertPool(certFile); err != nil {
+		t.Fatalf("expected valid ca bundle, got %v", err)
+	}
+
+	if _, err := LoadCertPool(keyFile); err == nil {
+		t.Fatal("expected error for a file without certificates")
+	}
+
+	if _, err := LoadCertPool(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := selfSigned(t, "first").WriteFiles(t, dir, "cert")
+
+	if _, err := NewReloader(certFile, keyFile, filepath.Join(dir, "missing.pem")); err == nil {
+		t.Fatal("expected error for a missing ca file")
+	}
+
+	reloader, err := NewReloader(certFile, keyFile, certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	reloader.OnError = func(err error) { errs = append(errs, err) }
+
+	cert, _ := reloader.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Fatalf("expected certificate %q, got %q", "first", name)
+	}
+
+	if reloader.ClientCAs() == nil {
+		t.Fatal("expected client ca bundle to be loaded")
+	}
+
+	// The files are not checked again before the interval.
+	selfSigned(t, "second").WriteFiles(t, dir, "cert")
+
+	cert, _ = reloader.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Fatalf("expected certificate %q before the check interval, got %q", "first", name)
+	}
+
+	reloader.CheckInterval = 0
+
+	cert, _ = reloader.GetCertificate(nil)
+	if name := commonName(t, cert); name != "second" {
+		t.Fatalf("expected rotated certificate %q, got %q", "second", name)
+	}
+
+	// A broken rotation keeps the previous certificate.
+	if err := os.WriteFile(keyFile, []byte("invalid"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ = reloader.GetCertificate(nil)
+	if name := commonName(t, cert); name != "second" {
+		t.Fatalf("expected previous certificate %q, got %q", "second", name)
+	}
+
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 reload error, got %v", errs)
+	}
+}
@@ -3,12 +3,35 @@ package event
 import (
 	"net/http"
 	"strings"
+	"unicode"
 
 	"github.com/dlbarduzzi/sentinel/tools/inflector"
 )
 
+// Stable machine-readable error codes returned in the `code` field.
+const (
+	CodeBadRequest          = "BAD_REQUEST"
+	CodeUnauthorized        = "UNAUTHORIZED"
+	CodeForbidden           = "FORBIDDEN"
+	CodeNotFound            = "NOT_FOUND"
+	CodeConflict            = "CONFLICT"
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeTooManyRequests     = "TOO_MANY_REQUESTS"
+	CodeInternalServerError = "INTERNAL_SERVER_ERROR"
+	CodeUnknown             = "UNKNOWN_ERROR"
+)
+
 type ApiError struct {
-	Status  int    `json:"status"`
+	Status    int                   `json:"status"`
+	Code      string                `json:"code"`
+	Message   string                `json:"message"`
+	Details   map[string]FieldError `json:"details,omitempty"`
+	RequestID string                `json:"request_id,omitempty"`
+}
+
+// FieldError describes a single invalid field in an ApiError details map.
+type FieldError struct {
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
@@ -17,23 +40,136 @@ func (e *ApiError) Error() string {
 	return e.Message
 }
 
+// WithDetails sets the field level errors and returns the same ApiError.
+func (e *ApiError) WithDetails(details map[string]FieldError) *ApiError {
+	e.Details = details
+	return e
+}
+
+// WithRequestID sets the request id and returns the same ApiError.
+func (e *ApiError) WithRequestID(requestID string) *ApiError {
+	e.RequestID = requestID
+	return e
+}
+
 func NewApiError(status int, message string) *ApiError {
+	return NewApiErrorWithCode(status, StatusCode(status), message)
+}
+
+func NewApiErrorWithCode(status int, code string, message string) *ApiError {
 	message = strings.TrimSpace(message)
 	if message == "" {
 		message = http.StatusText(status)
 	}
 
+	code = strings.TrimSpace(code)
+	if code == "" {
+		code = StatusCode(status)
+	}
+
 	return &ApiError{
 		Status:  status,
+		Code:    code,
+		Message: inflector.FormatSentence(message),
+	}
+}
+
+func NewFieldError(code string, message string) FieldError {
+	return FieldError{
+		Code:    code,
 		Message: inflector.FormatSentence(message),
 	}
 }
 
+func NewBadRequestError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "The request is malformed or contains invalid parameters."
+	}
+
+	return NewApiErrorWithCode(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func NewUnauthorizedError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "Missing or invalid authentication credentials."
+	}
+
+	return NewApiErrorWithCode(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func NewForbiddenError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "You are not allowed to perform this request."
+	}
+
+	return NewApiErrorWithCode(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NewNotFoundError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "The requested resource was not found."
+	}
+
+	return NewApiErrorWithCode(http.StatusNotFound, CodeNotFound, message)
+}
+
+func NewConflictError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "The request conflicts with the current state of the resource."
+	}
+
+	return NewApiErrorWithCode(http.StatusConflict, CodeConflict, message)
+}
+
+func NewValidationError(message string, details map[string]FieldError) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "Failed to validate request data."
+	}
+
+	return NewApiErrorWithCode(
+		http.StatusUnprocessableEntity, CodeValidationFailed, message,
+	).WithDetails(details)
+}
+
+func NewTooManyRequestsError(message string) *ApiError {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = "Too many requests, please try again later."
+	}
+
+	return NewApiErrorWithCode(http.StatusTooManyRequests, CodeTooManyRequests, message)
+}
+
 func NewInternalServerError(message string) *ApiError {
 	message = strings.TrimSpace(message)
 	if message == "" {
 		message = "Something went wrong while processing this request."
 	}
 
-	return NewApiError(http.StatusInternalServerError, message)
+	return NewApiErrorWithCode(http.StatusInternalServerError, CodeInternalServerError, message)
+}
+
+// StatusCode converts an http status into its error code form,
+// e.g. `404` into `NOT_FOUND`.
+func StatusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return CodeUnknown
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		if r == '\'' {
+			return -1
+		}
+		return '_'
+	}, text)
 }
@@ -18,19 +18,19 @@ func TestNewApiError(t *testing.T) {
 		{
 			name:    "status 300",
 			apiErr:  NewApiError(300, "hello world"),
-			content: `{"status":300,"message":"Hello world."}`,
+			content: `{"status":300,"code":"MULTIPLE_CHOICES","message":"Hello world."}`,
 			message: "Hello world.",
 		},
 		{
 			name:    "status 400",
 			apiErr:  NewApiError(400, ""),
-			content: `{"status":400,"message":"Bad Request."}`,
+			content: `{"status":400,"code":"BAD_REQUEST","message":"Bad Request."}`,
 			message: "Bad Request.",
 		},
 		{
 			name:    "status 500",
 			apiErr:  NewApiError(500, "Hello world!"),
-			content: `{"status":500,"message":"Hello world!"}`,
+			content: `{"status":500,"code":"INTERNAL_SERVER_ERROR","message":"Hello world!"}`,
 			message: "Hello world!",
 		},
 	}
@@ -71,6 +71,7 @@ func TestNewInternalServerError(t *testing.T) {
 			apiErr: NewInternalServerError(""),
 			content: []string{
 				`"status":500`,
+				`"code":"INTERNAL_SERVER_ERROR"`,
 				`Something went wrong while processing this request.`,
 			},
 			message: "Something went wrong while processing this request.",
@@ -109,3 +110,115 @@ func TestNewInternalServerError(t *testing.T) {
 		})
 	}
 }
+
+func TestNewApiErrorConstructors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name    string
+		apiErr  *ApiError
+		content []string
+		message string
+	}{
+		{
+			name:    "bad request",
+			apiErr:  NewBadRequestError(""),
+			content: []string{`"status":400`, `"code":"BAD_REQUEST"`},
+			message: "The request is malformed or contains invalid parameters.",
+		},
+		{
+			name:    "unauthorized",
+			apiErr:  NewUnauthorizedError(""),
+			content: []string{`"status":401`, `"code":"UNAUTHORIZED"`},
+			message: "Missing or invalid authentication credentials.",
+		},
+		{
+			name:    "forbidden",
+			apiErr:  NewForbiddenError("hello world"),
+			content: []string{`"status":403`, `"code":"FORBIDDEN"`},
+			message: "Hello world.",
+		},
+		{
+			name:    "not found",
+			apiErr:  NewNotFoundError(""),
+			content: []string{`"status":404`, `"code":"NOT_FOUND"`},
+			message: "The requested resource was not found.",
+		},
+		{
+			name:    "conflict",
+			apiErr:  NewConflictError(""),
+			content: []string{`"status":409`, `"code":"CONFLICT"`},
+			message: "The request conflicts with the current state of the resource.",
+		},
+		{
+			name: "validation",
+			apiErr: NewValidationError("", map[string]FieldError{
+				"name": NewFieldError("required", "cannot be blank"),
+			}),
+			content: []string{
+				`"status":422`,
+				`"code":"VALIDATION_FAILED"`,
+				`"details":{"name":{"code":"required","message":"Cannot be blank."}}`,
+			},
+			message: "Failed to validate request data.",
+		},
+		{
+			name:    "too many requests",
+			apiErr:  NewTooManyRequestsError(""),
+			content: []string{`"status":429`, `"code":"TOO_MANY_REQUESTS"`},
+			message: "Too many requests, please try again later.",
+		},
+		{
+			name:    "request id",
+			apiErr:  NewNotFoundError("").WithRequestID("abc123"),
+			content: []string{`"status":404`, `"request_id":"abc123"`},
+			message: "The requested resource was not found.",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := tc.apiErr
+
+			res, err := json.Marshal(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resStr := string(res)
+
+			for _, content := range tc.content {
+				if !strings.Contains(resStr, content) {
+					t.Errorf("expected content %v in response body \n%v", content, resStr)
+				}
+			}
+
+			if e.Error() != tc.message {
+				t.Fatalf("expected error message to be %q, got %q", tc.message, e.Error())
+			}
+		})
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		status   int
+		expected string
+	}{
+		{0, "UNKNOWN_ERROR"},
+		{400, "BAD_REQUEST"},
+		{418, "IM_A_TEAPOT"},
+		{422, "UNPROCESSABLE_ENTITY"},
+		{500, "INTERNAL_SERVER_ERROR"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			if code := StatusCode(tc.status); code != tc.expected {
+				t.Fatalf("expected code to be %q, got %q", tc.expected, code)
+			}
+		})
+	}
+}
@@ -2,15 +2,26 @@ package event
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strings"
+
+	"github.com/dlbarduzzi/sentinel/tools/hook"
 )
 
+// Event holds the request and response of an http request. It embeds
+// hook.Event so it can be passed through a hook handlers chain.
 type Event struct {
+	hook.Event
+
 	Request  *http.Request
 	Response http.ResponseWriter
 }
 
+// MaxBodySize is the maximum request body size read by BindJson.
+const MaxBodySize = 1 << 20
+
 func (e *Event) Json(data any, status int) error {
 	res, err := json.Marshal(data)
 	if err != nil {
@@ -45,10 +56,64 @@ func (e *Event) Text(status int, message string) error {
 	return nil
 }
 
+// BindJson decodes the json request body into dst. Unknown fields, trailing
+// data and bodies larger than MaxBodySize are rejected with a bad request
+// error.
+func (e *Event) BindJson(dst any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(e.Response, e.Request.Body, MaxBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		if errors.Is(err, io.EOF) {
+			return NewBadRequestError("Request body must not be empty.")
+		}
+		return NewBadRequestError("Failed to parse request body.")
+	}
+
+	if decoder.More() {
+		return NewBadRequestError("Request body must contain a single json object.")
+	}
+
+	return nil
+}
+
+// RequestID returns the id assigned to the current request, if any.
+func (e *Event) RequestID() string {
+	return RequestIDFromContext(e.Request.Context())
+}
+
 func (e *Event) Status(status int) error {
 	return e.Text(status, "")
 }
 
+func (e *Event) BadRequestError(message string) *ApiError {
+	return NewBadRequestError(message)
+}
+
+func (e *Event) UnauthorizedError(message string) *ApiError {
+	return NewUnauthorizedError(message)
+}
+
+func (e *Event) ForbiddenError(message string) *ApiError {
+	return NewForbiddenError(message)
+}
+
+func (e *Event) NotFoundError(message string) *ApiError {
+	return NewNotFoundError(message)
+}
+
+func (e *Event) ConflictError(message string) *ApiError {
+	return NewConflictError(message)
+}
+
+func (e *Event) ValidationError(message string, details map[string]FieldError) *ApiError {
+	return NewValidationError(message, details)
+}
+
+func (e *Event) TooManyRequestsError(message string) *ApiError {
+	return NewTooManyRequestsError(message)
+}
+
 func (e *Event) InternalServerError(message string) *ApiError {
 	return NewInternalServerError(message)
 }
@@ -163,7 +163,7 @@ func TestEventInternalServerError(t *testing.T) {
 	resStr := string(res)
 
 	message := "Something went wrong while processing this request."
-	content := fmt.Sprintf(`{"status":500,"message":"%s"}`, message)
+	content := fmt.Sprintf(`{"status":500,"code":"INTERNAL_SERVER_ERROR","message":"%s"}`, message)
 
 	if resStr != content {
 		t.Fatalf("expected content to be \n%v \ngot \n%v", content, resStr)
@@ -251,3 +251,48 @@ func testEvent(t *testing.T, tc testCase, fn func(e *Event) error) {
 		}
 	})
 }
+
+func TestEventBindJson(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+
+	testCases := []struct {
+		name          string
+		body          string
+		expectedName  string
+		expectedError bool
+	}{
+		{"valid body", `{"name":"test"}`, "test", false},
+		{"empty body", ``, "", true},
+		{"invalid json", `{"name":`, "", true},
+		{"unknown field", `{"name":"test","other":1}`, "test", true},
+		{"trailing data", `{"name":"test"}{}`, "test", true},
+		{"too large", `{"name":"` + strings.Repeat("a", MaxBodySize) + `"}`, "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Event{
+				Request:  httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body)),
+				Response: httptest.NewRecorder(),
+			}
+
+			var data payload
+			err := e.BindJson(&data)
+
+			if tc.expectedError {
+				var apiErr *ApiError
+				if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest {
+					t.Fatalf("expected bad request error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("expected error to be nil, got %v", err)
+			}
+
+			if data.Name != tc.expectedName {
+				t.Fatalf("expected name to be %q, got %q", tc.expectedName, data.Name)
+			}
+		})
+	}
+}
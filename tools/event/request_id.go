@@ -0,0 +1,55 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// HeaderRequestID is the header used to read and echo request ids.
+const HeaderRequestID = "X-Request-ID"
+
+// maxRequestIDLength limits the size of client provided request ids.
+const maxRequestIDLength = 128
+
+// contextKey is the event string type used to avoid context collisions.
+type contextKey string
+
+// requestIDKey identifies the request id value stored in the context.
+const requestIDKey = contextKey("request_id")
+
+// NewRequestID generates a random 32 characters hex request id.
+func NewRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// IsValidRequestID reports whether a client provided request id is safe
+// to be echoed back and written to logs.
+func IsValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		isAlpha := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+		isDigit := r >= '0' && r <= '9'
+		if !isAlpha && !isDigit && r != '-' && r != '_' && r != '.' && r != ':' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func RequestIDWithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey).(string); ok {
+		return id
+	}
+	return ""
+}
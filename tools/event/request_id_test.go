@@ -0,0 +1,66 @@
+package event
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestNewRequestID(t *testing.T) {
+	t.Parallel()
+
+	id1 := NewRequestID()
+	id2 := NewRequestID()
+
+	if len(id1) != 32 {
+		t.Fatalf("expected request id length to be 32, got %d", len(id1))
+	}
+
+	if id1 == id2 {
+		t.Fatalf("expected request ids to be unique, got %q twice", id1)
+	}
+
+	if !IsValidRequestID(id1) {
+		t.Fatalf("expected generated request id %q to be valid", id1)
+	}
+}
+
+func TestIsValidRequestID(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		id       string
+		expected bool
+	}{
+		{"", false},
+		{"abc-123", true},
+		{"trace:abc_123.4", true},
+		{"abc 123", false},
+		{"abc\n123", false},
+		{"<script>", false},
+		{strings.Repeat("a", 128), true},
+		{strings.Repeat("a", 129), false},
+	}
+
+	for _, tc := range testCases {
+		if valid := IsValidRequestID(tc.id); valid != tc.expected {
+			t.Errorf("expected request id %q validity to be %v, got %v", tc.id, tc.expected, valid)
+		}
+	}
+}
+
+func TestRequestIDContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	if id := RequestIDFromContext(ctx); id != "" {
+		t.Fatalf("expected empty request id, got %q", id)
+	}
+
+	ctx = RequestIDWithContext(ctx, "abc123")
+
+	if id := RequestIDFromContext(ctx); id != "abc123" {
+		t.Fatalf("expected request id to be %q, got %q", "abc123", id)
+	}
+}
@@ -0,0 +1,78 @@
+package event
+
+import (
+	"net/http"
+)
+
+// ResponseWriter wraps an http.ResponseWriter capturing the written
+// status code and the number of body bytes.
+type ResponseWriter struct {
+	http.ResponseWriter
+
+	status  int
+	size    int64
+	written bool
+}
+
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+func (w *ResponseWriter) WriteHeader(status int) {
+	if w.written {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.status = status
+	w.written = true
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Flush implements http.Flusher when supported by the wrapped writer.
+func (w *ResponseWriter) Flush() {
+	if !w.written {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, allowing http.ResponseController
+// to reach optional interfaces of the original writer.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Status returns the written status code. It defaults to 200 when no
+// explicit status was written, matching the net/http behavior.
+func (w *ResponseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of body bytes written.
+func (w *ResponseWriter) Size() int64 {
+	return w.size
+}
+
+// Written reports whether the response headers were already written.
+func (w *ResponseWriter) Written() bool {
+	return w.written
+}
@@ -0,0 +1,82 @@
+package event
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		write          func(w http.ResponseWriter)
+		expectedStatus int
+		expectedSize   int64
+		expectedWrite  bool
+	}{
+		{
+			name:           "nothing written",
+			write:          func(http.ResponseWriter) {},
+			expectedStatus: 200,
+			expectedSize:   0,
+			expectedWrite:  false,
+		},
+		{
+			name: "status only",
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			expectedStatus: 204,
+			expectedSize:   0,
+			expectedWrite:  true,
+		},
+		{
+			name: "implicit status",
+			write: func(w http.ResponseWriter) {
+				_, _ = w.Write([]byte("hello"))
+				_, _ = w.Write([]byte(" world"))
+			},
+			expectedStatus: 200,
+			expectedSize:   11,
+			expectedWrite:  true,
+		},
+		{
+			name: "status and body",
+			write: func(w http.ResponseWriter) {
+				w.WriteHeader(http.StatusNotFound)
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte("not found"))
+			},
+			expectedStatus: 404,
+			expectedSize:   9,
+			expectedWrite:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			w := NewResponseWriter(rec)
+
+			tc.write(w)
+
+			if w.Status() != tc.expectedStatus {
+				t.Fatalf("expected status to be %d, got %d", tc.expectedStatus, w.Status())
+			}
+
+			if w.Size() != tc.expectedSize {
+				t.Fatalf("expected size to be %d, got %d", tc.expectedSize, w.Size())
+			}
+
+			if w.Written() != tc.expectedWrite {
+				t.Fatalf("expected written to be %v, got %v", tc.expectedWrite, w.Written())
+			}
+
+			if w.Unwrap() != rec {
+				t.Fatal("expected unwrap to return the original response writer")
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultTimeout is the check timeout used when none is specified.
+const DefaultTimeout = time.Second * 5
+
+// ErrShuttingDown is reported by readiness while the app is shutting down.
+var ErrShuttingDown = errors.New("application is shutting down")
+
+// Status values of a check or report.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Kind defines which probes a check belongs to.
+type Kind int
+
+const (
+	// KindReadiness checks are run only by the readiness probe.
+	KindReadiness Kind = iota
+
+	// KindLiveness checks are run by both the liveness and readiness probes.
+	KindLiveness
+)
+
+// Check defines a named dependency check.
+type Check struct {
+	Name    string
+	Kind    Kind
+	Timeout time.Duration
+	Func    func(ctx context.Context) error
+}
+
+// Result holds the outcome of a single check run.
+type Result struct {
+	Name    string  `json:"name"`
+	Status  string  `json:"status"`
+	Latency float64 `json:"latency_ms"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// Report holds the aggregated outcome of a probe.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy reports whether every check of the report passed.
+func (r *Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+// Registry stores the checks registered by the app subsystems.
+// It is safe for concurrent use.
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []*Check
+	shuttingDown atomic.Bool
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a new check, replacing any existing check with the same name.
+func (r *Registry) Register(check Check) error {
+	check.Name = strings.TrimSpace(check.Name)
+	if check.Name == "" {
+		return errors.New("health check name is required")
+	}
+
+	if check.Func == nil {
+		return fmt.Errorf("health check %q has no check func", check.Name)
+	}
+
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = slices.DeleteFunc(r.checks, func(c *Check) bool {
+		return c.Name == check.Name
+	})
+	r.checks = append(r.checks, &check)
+
+	return nil
+}
+
+// Unregister removes the check with the given name, if any.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks = slices.DeleteFunc(r.checks, func(c *Check) bool {
+		return c.Name == name
+	})
+}
+
+// SetShuttingDown marks the app as shutting down, failing the readiness probe.
+func (r *Registry) SetShuttingDown(value bool) {
+	r.shuttingDown.Store(value)
+}
+
+// IsShuttingDown reports whether the app was marked as shutting down.
+func (r *Registry) IsShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Live runs the liveness checks.
+func (r *Registry) Live(ctx context.Context) *Report {
+	return r.run(ctx, KindLiveness)
+}
+
+// Ready runs every registered check. It fails right away, without running
+// any checks, once the app was marked as shutting down.
+func (r *Registry) Ready(ctx context.Context) *Report {
+	if r.IsShuttingDown() {
+		return &Report{
+			Status: StatusDown,
+			Checks: []Result{{
+				Name:   "shutdown",
+				Status: StatusDown,
+				Error:  ErrShuttingDown.Error(),
+			}},
+		}
+	}
+
+	return r.run(ctx, KindReadiness)
+}
+
+// run executes concurrently the checks of the given kind (liveness checks
+// are included in readiness) and aggregates their results.
+func (r *Registry) run(ctx context.Context, kind Kind) *Report {
+	r.mu.RLock()
+	checks := make([]*Check, 0, len(r.checks))
+	for _, c := range r.checks {
+		if kind == KindReadiness || c.Kind == KindLiveness {
+			checks = append(checks, c)
+		}
+	}
+	r.mu.RUnlock()
+
+	report := &Report{
+		Status: StatusUp,
+		Checks: make([]Result, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+
+	for i, c := range checks {
+		wg.Go(func() {
+			report.Checks[i] = runCheck(ctx, c)
+		})
+	}
+
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+		}
+	}
+
+	return report
+}
+
+func runCheck(ctx context.Context, check *Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, check.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errCh := make(chan error, 1)
+
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				errCh <- fmt.Errorf("recovered from panic: %v", rec)
+			}
+		}()
+		errCh <- check.Func(ctx)
+	}()
+
+	var err error
+
+	// Do not wait for checks that ignore their context cancellation.
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := Result{
+		Name:    check.Name,
+		Status:  StatusUp,
+		Latency: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
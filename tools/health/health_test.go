@@ -0,0 +1,129 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+
+	if err := r.Register(Check{Name: " ", Func: passing}); err == nil {
+		t.Fatal("expected empty name error, got nil")
+	}
+
+	if err := r.Register(Check{Name: "store"}); err == nil {
+		t.Fatal("expected missing func error, got nil")
+	}
+
+	if err := r.Register(Check{Name: "store", Func: passing}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Replaces the previous check with the same name.
+	if err := r.Register(Check{Name: "store", Func: failing}); err != nil {
+		t.Fatal(err)
+	}
+
+	report := r.Ready(t.Context())
+
+	if len(report.Checks) != 1 {
+		t.Fatalf("expected 1 check, got %d", len(report.Checks))
+	}
+
+	if report.Healthy() {
+		t.Fatal("expected report to be unhealthy")
+	}
+
+	r.Unregister("store")
+
+	if report := r.Ready(t.Context()); len(report.Checks) != 0 || !report.Healthy() {
+		t.Fatalf("expected healthy report without checks, got %+v", report)
+	}
+}
+
+func TestRegistryProbes(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+
+	checks := []Check{
+		{Name: "loop", Kind: KindLiveness, Func: passing},
+		{Name: "store", Kind: KindReadiness, Func: failing},
+		{
+			Name:    "slow",
+			Kind:    KindReadiness,
+			Timeout: time.Millisecond * 10,
+			Func: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+	}
+
+	for _, check := range checks {
+		if err := r.Register(check); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	live := r.Live(t.Context())
+
+	if !live.Healthy() || len(live.Checks) != 1 || live.Checks[0].Name != "loop" {
+		t.Fatalf("expected healthy liveness report with only the loop check, got %+v", live)
+	}
+
+	ready := r.Ready(t.Context())
+
+	if ready.Healthy() || len(ready.Checks) != 3 {
+		t.Fatalf("expected unhealthy readiness report with 3 checks, got %+v", ready)
+	}
+
+	expected := map[string]string{"loop": StatusUp, "store": StatusDown, "slow": StatusDown}
+
+	for _, result := range ready.Checks {
+		if result.Status != expected[result.Name] {
+			t.Errorf("expected %q check status to be %q, got %q", result.Name, expected[result.Name], result.Status)
+		}
+	}
+
+	if ready.Checks[2].Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected slow check error to be %q, got %q", context.DeadlineExceeded, ready.Checks[2].Error)
+	}
+}
+
+func TestRegistryShuttingDown(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+
+	if err := r.Register(Check{Name: "loop", Kind: KindLiveness, Func: passing}); err != nil {
+		t.Fatal(err)
+	}
+
+	r.SetShuttingDown(true)
+
+	if !r.IsShuttingDown() {
+		t.Fatal("expected registry to be shutting down")
+	}
+
+	if report := r.Ready(t.Context()); report.Healthy() {
+		t.Fatalf("expected unhealthy readiness report, got %+v", report)
+	}
+
+	if report := r.Live(t.Context()); !report.Healthy() {
+		t.Fatalf("expected healthy liveness report, got %+v", report)
+	}
+}
+
+func passing(context.Context) error {
+	return nil
+}
+
+func failing(context.Context) error {
+	return errors.New("unreachable")
+}
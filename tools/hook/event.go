@@ -0,0 +1,35 @@
+package hook
+
+// Resolver defines the interface of a hook event.
+type Resolver interface {
+	// Next triggers the next handler in the hook chain.
+	Next() error
+
+	nextFunc() func() error
+	setNextFunc(fn func() error)
+}
+
+// Ensures that the Event implements the Resolver interface.
+var _ Resolver = (*Event)(nil)
+
+// Event implements Resolver and it is intended to be embedded in the
+// concrete hook event structs.
+type Event struct {
+	next func() error
+}
+
+// Next calls the next hook handler, if any.
+func (e *Event) Next() error {
+	if e.next != nil {
+		return e.next()
+	}
+	return nil
+}
+
+func (e *Event) nextFunc() func() error {
+	return e.next
+}
+
+func (e *Event) setNextFunc(fn func() error) {
+	e.next = fn
+}
@@ -0,0 +1,121 @@
+package hook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"slices"
+	"sync"
+)
+
+// Handler defines a single Hook handler. Handlers with lower priority
+// run first, handlers with equal priority run in the order they were bound.
+type Handler[T Resolver] struct {
+	// Func defines the handler function to execute.
+	//
+	// Note that users need to call e.Next() in order to proceed with
+	// the execution of the remaining hook handlers.
+	Func func(T) error
+
+	// ID is the unique identifier of the handler. It is autogenerated
+	// when empty and it can be used later to unbind the handler.
+	ID string
+
+	// Priority allows changing the default order of execution.
+	Priority int
+}
+
+// Hook defines a generic and concurrent safe structure for managing
+// event hooks. Every hook handler must call e.Next() to continue the
+// execution of the chain.
+type Hook[T Resolver] struct {
+	mu       sync.RWMutex
+	handlers []*Handler[T]
+}
+
+// Bind registers the provided handler to the current hook queue and
+// returns its id. Binding a handler with an existing id replaces it.
+func (h *Hook[T]) Bind(handler *Handler[T]) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if handler.ID == "" {
+		handler.ID = generateID()
+	}
+
+	h.handlers = slices.DeleteFunc(h.handlers, func(existing *Handler[T]) bool {
+		return existing.ID == handler.ID
+	})
+
+	h.handlers = append(h.handlers, handler)
+
+	slices.SortStableFunc(h.handlers, func(a, b *Handler[T]) int {
+		return a.Priority - b.Priority
+	})
+
+	return handler.ID
+}
+
+// BindFunc registers a new handler with the specified function and
+// returns its autogenerated id.
+func (h *Hook[T]) BindFunc(fn func(e T) error) string {
+	return h.Bind(&Handler[T]{Func: fn})
+}
+
+// Unbind removes the handlers with the given ids from the hook queue.
+func (h *Hook[T]) Unbind(ids ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers = slices.DeleteFunc(h.handlers, func(handler *Handler[T]) bool {
+		return slices.Contains(ids, handler.ID)
+	})
+}
+
+// UnbindAll removes every registered handler.
+func (h *Hook[T]) UnbindAll() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers = nil
+}
+
+// Length returns the number of registered handlers.
+func (h *Hook[T]) Length() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.handlers)
+}
+
+// Trigger executes all registered hook handlers one by one with the
+// specified event. The optional oneOffHandlerFuncs are executed after
+// the registered handlers, in the order they were passed, which makes
+// them suitable as the finalizer of the chain.
+func (h *Hook[T]) Trigger(event T, oneOffHandlerFuncs ...func(T) error) error {
+	h.mu.RLock()
+	fns := make([]func(T) error, 0, len(h.handlers)+len(oneOffHandlerFuncs))
+	for _, handler := range h.handlers {
+		fns = append(fns, handler.Func)
+	}
+	fns = append(fns, oneOffHandlerFuncs...)
+	h.mu.RUnlock()
+
+	// Reset in case the event is being reused.
+	event.setNextFunc(nil)
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		next := event.nextFunc()
+		event.setNextFunc(func() error {
+			event.setNextFunc(next)
+			return fns[i](event)
+		})
+	}
+
+	return event.Next()
+}
+
+func generateID() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
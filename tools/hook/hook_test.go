@@ -0,0 +1,171 @@
+package hook
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type testEvent struct {
+	Event
+	calls string
+}
+
+func TestHookBindAndTrigger(t *testing.T) {
+	t.Parallel()
+
+	h := &Hook[*testEvent]{}
+
+	h.BindFunc(func(e *testEvent) error {
+		e.calls += "a"
+		return e.Next()
+	})
+
+	h.Bind(&Handler[*testEvent]{
+		ID:       "first",
+		Priority: -1,
+		Func: func(e *testEvent) error {
+			e.calls += "b"
+			return e.Next()
+		},
+	})
+
+	h.BindFunc(func(e *testEvent) error {
+		e.calls += "c"
+		return e.Next()
+	})
+
+	if h.Length() != 3 {
+		t.Fatalf("expected 3 handlers, got %d", h.Length())
+	}
+
+	e := &testEvent{}
+
+	err := h.Trigger(e, func(e *testEvent) error {
+		e.calls += "d"
+		return e.Next()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.calls != "bacd" {
+		t.Fatalf("expected calls to be %q, got %q", "bacd", e.calls)
+	}
+
+	// The same event can be triggered again.
+	e.calls = ""
+
+	if err := h.Trigger(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.calls != "bac" {
+		t.Fatalf("expected calls to be %q, got %q", "bac", e.calls)
+	}
+}
+
+func TestHookBindReplace(t *testing.T) {
+	t.Parallel()
+
+	h := &Hook[*testEvent]{}
+
+	h.Bind(&Handler[*testEvent]{ID: "test", Func: func(e *testEvent) error {
+		e.calls += "a"
+		return e.Next()
+	}})
+
+	h.Bind(&Handler[*testEvent]{ID: "test", Func: func(e *testEvent) error {
+		e.calls += "b"
+		return e.Next()
+	}})
+
+	e := &testEvent{}
+
+	if err := h.Trigger(e); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.calls != "b" {
+		t.Fatalf("expected calls to be %q, got %q", "b", e.calls)
+	}
+}
+
+func TestHookUnbind(t *testing.T) {
+	t.Parallel()
+
+	h := &Hook[*testEvent]{}
+
+	id1 := h.BindFunc(func(e *testEvent) error { return e.Next() })
+	id2 := h.BindFunc(func(e *testEvent) error { return e.Next() })
+	h.BindFunc(func(e *testEvent) error { return e.Next() })
+
+	if id1 == "" || id1 == id2 {
+		t.Fatalf("expected unique autogenerated ids, got %q and %q", id1, id2)
+	}
+
+	h.Unbind(id1, id2, "missing")
+
+	if h.Length() != 1 {
+		t.Fatalf("expected 1 handler, got %d", h.Length())
+	}
+
+	h.UnbindAll()
+
+	if h.Length() != 0 {
+		t.Fatalf("expected 0 handlers, got %d", h.Length())
+	}
+}
+
+func TestHookTriggerError(t *testing.T) {
+	t.Parallel()
+
+	h := &Hook[*testEvent]{}
+	errTest := errors.New("test")
+
+	h.BindFunc(func(e *testEvent) error {
+		e.calls += "a"
+		return e.Next()
+	})
+
+	h.BindFunc(func(e *testEvent) error {
+		e.calls += "b"
+		return errTest
+	})
+
+	e := &testEvent{}
+
+	err := h.Trigger(e, func(e *testEvent) error {
+		e.calls += "c"
+		return nil
+	})
+	if !errors.Is(err, errTest) {
+		t.Fatalf("expected error %v, got %v", errTest, err)
+	}
+
+	// The chain stops at the handler returning the error.
+	if e.calls != "ab" {
+		t.Fatalf("expected calls to be %q, got %q", "ab", e.calls)
+	}
+}
+
+func TestHookConcurrentBind(t *testing.T) {
+	t.Parallel()
+
+	h := &Hook[*testEvent]{}
+
+	var wg sync.WaitGroup
+
+	for range 50 {
+		wg.Go(func() {
+			h.BindFunc(func(e *testEvent) error { return e.Next() })
+			_ = h.Trigger(&testEvent{})
+		})
+	}
+
+	wg.Wait()
+
+	if h.Length() != 50 {
+		t.Fatalf("expected 50 handlers, got %d", h.Length())
+	}
+}
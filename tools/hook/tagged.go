@@ -0,0 +1,65 @@
+package hook
+
+import "slices"
+
+// Tagger defines the interface of hook events that are tagged,
+// e.g. with the name of the cluster or rule group they relate to.
+type Tagger interface {
+	Resolver
+
+	Tags() []string
+}
+
+// TaggedHook defines a proxy hook which registers handlers that are
+// triggered only when the event tags match the hook tags. A TaggedHook
+// without tags is triggered for every event.
+type TaggedHook[T Tagger] struct {
+	mainHook *Hook[T]
+	tags     []string
+}
+
+// NewTaggedHook creates a new TaggedHook proxy for the given main hook.
+func NewTaggedHook[T Tagger](mainHook *Hook[T], tags ...string) *TaggedHook[T] {
+	return &TaggedHook[T]{mainHook: mainHook, tags: tags}
+}
+
+// CanTriggerOn checks if the current TaggedHook can be triggered with
+// the provided event tags.
+func (h *TaggedHook[T]) CanTriggerOn(tagsToCheck []string) bool {
+	if len(h.tags) == 0 {
+		return true
+	}
+
+	for _, tag := range tagsToCheck {
+		if slices.Contains(h.tags, tag) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Bind registers the handler to the main hook, wrapping it so that it is
+// skipped for events whose tags do not match.
+func (h *TaggedHook[T]) Bind(handler *Handler[T]) string {
+	fn := handler.Func
+
+	handler.Func = func(e T) error {
+		if h.CanTriggerOn(e.Tags()) {
+			return fn(e)
+		}
+		return e.Next()
+	}
+
+	return h.mainHook.Bind(handler)
+}
+
+// BindFunc registers a new tagged handler with the specified function.
+func (h *TaggedHook[T]) BindFunc(fn func(e T) error) string {
+	return h.Bind(&Handler[T]{Func: fn})
+}
+
+// Unbind removes the handlers with the given ids from the main hook.
+func (h *TaggedHook[T]) Unbind(ids ...string) {
+	h.mainHook.Unbind(ids...)
+}
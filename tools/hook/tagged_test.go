@@ -0,0 +1,63 @@
+package hook
+
+import "testing"
+
+type testTaggedEvent struct {
+	Event
+	tags  []string
+	calls string
+}
+
+func (e *testTaggedEvent) Tags() []string {
+	return e.tags
+}
+
+func TestTaggedHook(t *testing.T) {
+	t.Parallel()
+
+	mainHook := &Hook[*testTaggedEvent]{}
+
+	NewTaggedHook(mainHook).BindFunc(func(e *testTaggedEvent) error {
+		e.calls += "a"
+		return e.Next()
+	})
+
+	NewTaggedHook(mainHook, "prod", "stage").BindFunc(func(e *testTaggedEvent) error {
+		e.calls += "b"
+		return e.Next()
+	})
+
+	id := NewTaggedHook(mainHook, "dev").BindFunc(func(e *testTaggedEvent) error {
+		e.calls += "c"
+		return e.Next()
+	})
+
+	testCases := []struct {
+		tags     []string
+		expected string
+	}{
+		{nil, "a"},
+		{[]string{"prod"}, "ab"},
+		{[]string{"stage", "dev"}, "abc"},
+		{[]string{"dev"}, "ac"},
+		{[]string{"other"}, "a"},
+	}
+
+	for _, tc := range testCases {
+		e := &testTaggedEvent{tags: tc.tags}
+
+		if err := mainHook.Trigger(e); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.calls != tc.expected {
+			t.Errorf("expected calls for tags %v to be %q, got %q", tc.tags, tc.expected, e.calls)
+		}
+	}
+
+	NewTaggedHook(mainHook).Unbind(id)
+
+	if mainHook.Length() != 2 {
+		t.Fatalf("expected 2 handlers, got %d", mainHook.Length())
+	}
+}
@@ -0,0 +1,293 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = 3 * time.Second
+)
+
+// Record is a log record collected by a BatchHandler. Attrs holds the
+// record and logger attributes, with groups as nested maps.
+type Record struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+// BatchConfig defines a BatchHandler configuration option.
+type BatchConfig struct {
+	// Level is the minimum level of the collected records.
+	Level slog.Leveler
+
+	// BatchSize is the number of records that triggers a write. Up to
+	// 10 batches are buffered while writing, the oldest records being
+	// dropped past that, see BatchHandler.Dropped and OnDrop.
+	BatchSize int
+
+	// FlushInterval is the maximum time a record is buffered.
+	FlushInterval time.Duration
+
+	// Write persists a batch of records. It must not log through a
+	// logger using the handler.
+	Write func(ctx context.Context, records []Record) error
+
+	// OnError is called with the Write errors. The failed batch is
+	// dropped.
+	OnError func(err error)
+
+	// OnDrop is called before a write with the number of records dropped
+	// since the previous one because the buffer was full. It must not log
+	// through a logger using the handler.
+	OnDrop func(n uint64)
+
+	// Redact masks the sensitive values, see Config.Redact.
+	Redact *RedactConfig
+}
+
+// BatchHandler is a slog.Handler buffering the records and writing them
+// in batches, e.g. to a database. Close must be called to write the
+// buffered records before exiting.
+type BatchHandler struct {
+	*batch
+
+	attrs  []slog.Attr
+	groups []string
+}
+
+// batch is the buffer shared by a BatchHandler and its derived handlers.
+type batch struct {
+	config   BatchConfig
+	redactor *Redactor
+
+	mu      sync.Mutex
+	records []Record
+
+	// dropped counts the records dropped since the handler creation and
+	// reported the ones already passed to OnDrop.
+	dropped  atomic.Uint64
+	reported uint64
+
+	// writeMu keeps the batches in order.
+	writeMu sync.Mutex
+
+	full chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+func NewBatchHandler(config BatchConfig) *BatchHandler {
+	if config.Level == nil {
+		config.Level = slog.LevelInfo
+	}
+
+	if config.BatchSize < 1 {
+		config.BatchSize = DefaultBatchSize
+	}
+
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+
+	redact := DefaultRedactConfig()
+	if config.Redact != nil {
+		redact = *config.Redact
+	}
+
+	b := &batch{
+		config:   config,
+		redactor: NewRedactor(redact),
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go b.run()
+
+	return &BatchHandler{batch: b}
+}
+
+func (h *BatchHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.config.Level.Level()
+}
+
+func (h *BatchHandler) Handle(_ context.Context, record slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+record.NumAttrs())
+
+	// The logger attributes are already nested in their groups, and the
+	// record ones are added to the groups opened with WithGroup.
+	for _, attr := range h.attrs {
+		h.addAttr(attrs, attr)
+	}
+
+	target := attrs
+	for _, group := range h.groups {
+		m, ok := target[group].(map[string]any)
+		if !ok {
+			m = make(map[string]any)
+			target[group] = m
+		}
+		target = m
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		h.addAttr(target, attr)
+		return true
+	})
+
+	h.mu.Lock()
+	if limit := 10 * h.config.BatchSize; len(h.records) >= limit {
+		n := len(h.records) - limit + 1
+		h.records = h.records[n:]
+		h.dropped.Add(uint64(n))
+	}
+	h.records = append(h.records, Record{
+		Time:    record.Time,
+		Level:   record.Level,
+		Message: h.redactor.RedactString(record.Message),
+		Attrs:   attrs,
+	})
+	full := len(h.records) >= h.config.BatchSize
+	h.mu.Unlock()
+
+	// The batch is written in the background to not block the caller.
+	if full {
+		select {
+		case h.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (h *BatchHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// Attributes added inside a group are nested in it.
+	for i := len(h.groups) - 1; i >= 0; i-- {
+		attrs = []slog.Attr{{Key: h.groups[i], Value: slog.GroupValue(attrs...)}}
+	}
+
+	return &BatchHandler{
+		batch:  h.batch,
+		attrs:  append(append([]slog.Attr{}, h.attrs...), attrs...),
+		groups: h.groups,
+	}
+}
+
+func (h *BatchHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &BatchHandler{
+		batch:  h.batch,
+		attrs:  h.attrs,
+		groups: append(append([]string{}, h.groups...), name),
+	}
+}
+
+// Dropped returns the number of records dropped because the buffer was
+// full, since the handler creation.
+func (h *BatchHandler) Dropped() uint64 {
+	return h.dropped.Load()
+}
+
+// Close stops the flush interval and writes the buffered records.
+func (h *BatchHandler) Close(ctx context.Context) error {
+	h.once.Do(func() { close(h.stop) })
+	<-h.done
+
+	return h.flushWithError(ctx)
+}
+
+func (b *batch) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.flush(context.Background())
+		case <-b.full:
+			b.flush(context.Background())
+		}
+	}
+}
+
+func (b *batch) flush(ctx context.Context) {
+	if err := b.flushWithError(ctx); err != nil && b.config.OnError != nil {
+		b.config.OnError(err)
+	}
+}
+
+func (b *batch) flushWithError(ctx context.Context) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	b.mu.Lock()
+	records := b.records
+	b.records = nil
+	b.mu.Unlock()
+
+	// The drops are reported outside of Handle, which must not block.
+	if dropped := b.dropped.Load(); dropped > b.reported {
+		if b.config.OnDrop != nil {
+			b.config.OnDrop(dropped - b.reported)
+		}
+		b.reported = dropped
+	}
+
+	if len(records) == 0 || b.config.Write == nil {
+		return nil
+	}
+
+	return b.config.Write(ctx, records)
+}
+
+func (b *batch) addAttr(m map[string]any, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+
+	if attr.Value.Kind() == slog.KindGroup {
+		group := attr.Value.Group()
+		if len(group) == 0 {
+			return
+		}
+
+		// Attributes of groups without a key are inlined.
+		target := m
+		if attr.Key != "" {
+			sub, ok := m[attr.Key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any, len(group))
+				m[attr.Key] = sub
+			}
+			target = sub
+		}
+
+		for _, a := range group {
+			b.addAttr(target, a)
+		}
+
+		return
+	}
+
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	attr = b.redactor.RedactAttr(attr)
+
+	m[attr.Key] = attr.Value.Any()
+}
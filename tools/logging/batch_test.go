@@ -0,0 +1,189 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// batchRecorder collects the batches written by a BatchHandler.
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches [][]Record
+}
+
+func (r *batchRecorder) write(_ context.Context, records []Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.batches = append(r.batches, records)
+	return nil
+}
+
+func (r *batchRecorder) records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var records []Record
+	for _, batch := range r.batches {
+		records = append(records, batch...)
+	}
+	return records
+}
+
+func TestBatchHandler(t *testing.T) {
+	recorder := &batchRecorder{}
+
+	handler := NewBatchHandler(BatchConfig{
+		Level:         slog.LevelInfo,
+		FlushInterval: time.Hour,
+		Write:         recorder.write,
+	})
+
+	logger := slog.New(handler).With(slog.String("request_id", "abc")).WithGroup("req")
+
+	logger.Debug("debug message")
+	logger.Info("info message", slog.Int("status", 200), slog.String("password", "hunter2"))
+	logger.With(slog.String("path", "/")).Error("error message", slog.Any("error", errors.New("failed")))
+
+	if len(recorder.records()) != 0 {
+		t.Fatal("expected records to be buffered until close")
+	}
+
+	if err := handler.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	records := recorder.records()
+
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+
+	info := records[0]
+
+	if info.Message != "info message" || info.Level != slog.LevelInfo {
+		t.Fatalf("expected info message record, got %+v", info)
+	}
+
+	if info.Attrs["request_id"] != "abc" {
+		t.Fatalf("expected request_id attr to be %q, got %v", "abc", info.Attrs["request_id"])
+	}
+
+	group, _ := info.Attrs["req"].(map[string]any)
+	if group["status"] != int64(200) || group["password"] != Redacted {
+		t.Fatalf("expected req group with status and redacted password, got %v", info.Attrs["req"])
+	}
+
+	group, _ = records[1].Attrs["req"].(map[string]any)
+	if group["path"] != "/" || group["error"] != "failed" {
+		t.Fatalf("expected req group with path and error, got %v", records[1].Attrs["req"])
+	}
+}
+
+func TestBatchHandlerFlush(t *testing.T) {
+	recorder := &batchRecorder{}
+
+	handler := NewBatchHandler(BatchConfig{
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+		Write:         recorder.write,
+	})
+	defer handler.Close(context.Background())
+
+	logger := slog.New(handler)
+
+	// A full batch is written without waiting for the interval.
+	logger.Info("first")
+	logger.Info("second")
+
+	waitFor(t, func() bool { return len(recorder.records()) == 2 })
+
+	handler = NewBatchHandler(BatchConfig{
+		FlushInterval: 10 * time.Millisecond,
+		Write:         recorder.write,
+	})
+	defer handler.Close(context.Background())
+
+	slog.New(handler).Info("third")
+
+	waitFor(t, func() bool { return len(recorder.records()) == 3 })
+}
+
+func TestBatchHandlerErrors(t *testing.T) {
+	errTest := errors.New("test")
+	errs := make(chan error, 1)
+
+	handler := NewBatchHandler(BatchConfig{
+		BatchSize: 1,
+		Write:     func(context.Context, []Record) error { return errTest },
+		OnError:   func(err error) { errs <- err },
+	})
+
+	slog.New(handler).Info("message")
+
+	select {
+	case err := <-errs:
+		if !errors.Is(err, errTest) {
+			t.Fatalf("expected error %v, got %v", errTest, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected write error to be reported")
+	}
+
+	slog.New(handler).Info("message")
+
+	if err := handler.Close(context.Background()); err != nil && !errors.Is(err, errTest) {
+		t.Fatalf("expected close error to be nil or %v, got %v", errTest, err)
+	}
+}
+
+func TestBatchHandlerDropped(t *testing.T) {
+	release := make(chan struct{})
+	written := make(chan struct{}, 1)
+
+	var reported atomic.Uint64
+
+	handler := NewBatchHandler(BatchConfig{
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		Write: func(context.Context, []Record) error {
+			select {
+			case written <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		},
+		OnDrop: func(n uint64) { reported.Add(n) },
+	})
+
+	logger := slog.New(handler)
+	logger.Info("blocked")
+
+	// The first batch is being written, the next 10 are buffered and the
+	// oldest ones dropped past that.
+	<-written
+
+	for range 15 {
+		logger.Info("message")
+	}
+
+	if dropped := handler.Dropped(); dropped != 5 {
+		t.Fatalf("expected 5 dropped records, got %d", dropped)
+	}
+
+	close(release)
+
+	if err := handler.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := reported.Load(); n != 5 {
+		t.Fatalf("expected the 5 dropped records to be reported, got %d", n)
+	}
+}
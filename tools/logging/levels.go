@@ -0,0 +1,266 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ComponentKey is the attribute key naming the component of a logger,
+// see Component.
+const ComponentKey = "component"
+
+// Component returns a child logger for the given component. Its minimum
+// level can be changed independently through Levels.
+func Component(logger *slog.Logger, name string) *slog.Logger {
+	return logger.With(slog.String(ComponentKey, name))
+}
+
+// LevelState is the level of the root logger or of a component.
+type LevelState struct {
+	Level LogLevel
+
+	// ExpiresAt is the time the level reverts, zero when permanent.
+	ExpiresAt time.Time
+}
+
+// Levels controls the minimum level of a logger and of its components at
+// runtime. Components without a level of their own use the root level.
+type Levels struct {
+	root slog.LevelVar
+
+	mu         sync.RWMutex
+	rootState  LevelState
+	components map[string]*componentLevel
+	revert     map[string]*time.Timer
+
+	// rootBase is the level set without a ttl, which the temporary root
+	// levels revert to.
+	rootBase LogLevel
+}
+
+type componentLevel struct {
+	level slog.LevelVar
+	state LevelState
+
+	// base is the level set without a ttl, empty when the component
+	// reverts to the root level.
+	base LogLevel
+}
+
+func NewLevels(level LogLevel) *Levels {
+	if !level.IsValid() {
+		level = DefaultLevel
+	}
+
+	l := &Levels{
+		components: make(map[string]*componentLevel),
+		revert:     make(map[string]*time.Timer),
+	}
+
+	l.root.Set(SlogLevel(level))
+	l.rootState = LevelState{Level: level}
+	l.rootBase = level
+
+	return l
+}
+
+// Level returns the level of the given component, or of the root logger
+// when the component is empty.
+func (l *Levels) Level(component string) LevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if c, ok := l.components[component]; ok && component != "" {
+		return c.state
+	}
+
+	return l.rootState
+}
+
+// Components returns the components with a level of their own.
+func (l *Levels) Components() map[string]LevelState {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	states := make(map[string]LevelState, len(l.components))
+	for name, c := range l.components {
+		states[name] = c.state
+	}
+
+	return states
+}
+
+// Set changes the level of the given component, or of the root logger when
+// the component is empty. When ttl is greater than 0 the change reverts
+// after ttl to the last level set without a ttl: the root level to its
+// permanent level and the component to its own, or to the root level
+// when it has none.
+func (l *Levels) Set(component string, level LogLevel, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.revert[component]; ok {
+		timer.Stop()
+		delete(l.revert, component)
+	}
+
+	state := LevelState{Level: level}
+	if ttl > 0 {
+		state.ExpiresAt = time.Now().Add(ttl).UTC()
+	}
+
+	if component == "" {
+		if ttl <= 0 {
+			l.rootBase = level
+		}
+
+		l.root.Set(SlogLevel(level))
+		l.rootState = state
+
+		if ttl > 0 {
+			l.schedule(component, ttl, func() {
+				l.root.Set(SlogLevel(l.rootBase))
+				l.rootState = LevelState{Level: l.rootBase}
+			})
+		}
+
+		return
+	}
+
+	c, ok := l.components[component]
+	if !ok {
+		c = &componentLevel{}
+		l.components[component] = c
+	}
+
+	if ttl <= 0 {
+		c.base = level
+	}
+
+	c.level.Set(SlogLevel(level))
+	c.state = state
+
+	if ttl > 0 {
+		l.schedule(component, ttl, func() {
+			if c.base == "" {
+				delete(l.components, component)
+				return
+			}
+
+			c.level.Set(SlogLevel(c.base))
+			c.state = LevelState{Level: c.base}
+		})
+	}
+}
+
+// SetBase changes the permanent root level. Unlike Set, a temporary root
+// level set with a ttl stays in effect until it expires, and then reverts
+// to level. It reports whether such a temporary level is in effect.
+func (l *Levels) SetBase(level LogLevel) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.rootBase = level
+
+	if _, ok := l.revert[""]; ok {
+		return true
+	}
+
+	l.root.Set(SlogLevel(level))
+	l.rootState = LevelState{Level: level}
+
+	return false
+}
+
+// Reset removes the level of the given component, which uses the root
+// level again.
+func (l *Levels) Reset(component string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if timer, ok := l.revert[component]; ok {
+		timer.Stop()
+		delete(l.revert, component)
+	}
+
+	delete(l.components, component)
+}
+
+// schedule runs fn with the lock held after ttl, unless the component
+// level changes before. It must be called with the lock held.
+func (l *Levels) schedule(component string, ttl time.Duration, fn func()) {
+	var timer *time.Timer
+
+	timer = time.AfterFunc(ttl, func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		// A newer Set or Reset replaced this revert.
+		if l.revert[component] != timer {
+			return
+		}
+
+		delete(l.revert, component)
+		fn()
+	})
+
+	l.revert[component] = timer
+}
+
+// leveler returns the level used by the loggers of the given component.
+func (l *Levels) leveler(component string) slog.Leveler {
+	if component == "" {
+		return &l.root
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if c, ok := l.components[component]; ok {
+		return &c.level
+	}
+
+	return &l.root
+}
+
+// levelHandler filters records with the Levels of its component, so
+// level changes apply to the loggers already derived from it.
+type levelHandler struct {
+	handler   slog.Handler
+	levels    *Levels
+	component string
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.leveler(h.component).Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := h.component
+
+	for _, attr := range attrs {
+		if attr.Key == ComponentKey {
+			component = attr.Value.String()
+		}
+	}
+
+	return &levelHandler{
+		handler:   h.handler.WithAttrs(attrs),
+		levels:    h.levels,
+		component: component,
+	}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{
+		handler:   h.handler.WithGroup(name),
+		levels:    h.levels,
+		component: h.component,
+	}
+}
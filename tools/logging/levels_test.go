@@ -0,0 +1,158 @@
+package logging
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestLevelsComponents(t *testing.T) {
+	levels := NewLevels(LevelInfo)
+	logger := NewLoggerWithConfig(Config{Levels: levels})
+
+	ctx := t.Context()
+	sync := Component(logger, "sync")
+	http := Component(logger, "http").With(slog.String("request_id", "abc"))
+
+	if sync.Enabled(ctx, slog.LevelDebug) || http.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected components to use the root level")
+	}
+
+	levels.Set("sync", LevelDebug, 0)
+
+	if !sync.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected sync debug level to be enabled")
+	}
+
+	if http.Enabled(ctx, slog.LevelDebug) || logger.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected other loggers to keep the root level")
+	}
+
+	// Changes to the root level apply to existing loggers.
+	levels.Set("", LevelError, 0)
+
+	if logger.Enabled(ctx, slog.LevelWarn) || http.Enabled(ctx, slog.LevelWarn) {
+		t.Fatal("expected warn level to be disabled")
+	}
+
+	if !sync.Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("expected sync to keep its own level")
+	}
+
+	levels.Reset("sync")
+
+	if sync.Enabled(ctx, slog.LevelWarn) {
+		t.Fatal("expected sync to use the root level after reset")
+	}
+
+	if len(levels.Components()) != 0 {
+		t.Fatalf("expected no component levels, got %v", levels.Components())
+	}
+}
+
+func TestLevelsState(t *testing.T) {
+	levels := NewLevels("verbose")
+
+	if state := levels.Level(""); state.Level != DefaultLevel || !state.ExpiresAt.IsZero() {
+		t.Fatalf("expected root level to be %q with no expiry, got %+v", DefaultLevel, state)
+	}
+
+	levels.Set("store", LevelWarn, time.Hour)
+
+	state := levels.Level("store")
+	if state.Level != LevelWarn || state.ExpiresAt.IsZero() {
+		t.Fatalf("expected store level to be %q with expiry, got %+v", LevelWarn, state)
+	}
+
+	if state := levels.Level("sync"); state.Level != DefaultLevel {
+		t.Fatalf("expected sync level to be %q, got %q", DefaultLevel, state.Level)
+	}
+
+	if state := levels.Components()["store"]; state.Level != LevelWarn {
+		t.Fatalf("expected store component level to be %q, got %q", LevelWarn, state.Level)
+	}
+}
+
+func TestLevelsRevert(t *testing.T) {
+	levels := NewLevels(LevelInfo)
+	logger := NewLoggerWithConfig(Config{Levels: levels})
+	sync := Component(logger, "sync")
+
+	levels.Set("", LevelDebug, 20*time.Millisecond)
+	levels.Set("sync", LevelError, 20*time.Millisecond)
+
+	// A newer change cancels the pending revert.
+	levels.Set("http", LevelDebug, 20*time.Millisecond)
+	levels.Set("http", LevelWarn, 0)
+
+	if !logger.Enabled(t.Context(), slog.LevelDebug) {
+		t.Fatal("expected debug level to be enabled")
+	}
+
+	waitFor(t, func() bool {
+		return levels.Level("").Level == LevelInfo && len(levels.Components()) == 1
+	})
+
+	if logger.Enabled(t.Context(), slog.LevelDebug) {
+		t.Fatal("expected debug level to be disabled after revert")
+	}
+
+	if !sync.Enabled(t.Context(), slog.LevelInfo) {
+		t.Fatal("expected sync to use the root level after revert")
+	}
+
+	if state := levels.Level("http"); state.Level != LevelWarn {
+		t.Fatalf("expected http level to be %q, got %q", LevelWarn, state.Level)
+	}
+}
+
+func TestLevelsRevertToBase(t *testing.T) {
+	levels := NewLevels(LevelInfo)
+
+	// A second temporary level reverts to the permanent one, not to the
+	// first temporary level.
+	levels.Set("", LevelDebug, time.Hour)
+	levels.Set("", LevelWarn, 20*time.Millisecond)
+
+	levels.Set("sync", LevelError, 0)
+	levels.Set("sync", LevelDebug, time.Hour)
+	levels.Set("sync", LevelWarn, 20*time.Millisecond)
+
+	waitFor(t, func() bool {
+		return levels.Level("").Level == LevelInfo && levels.Level("sync").Level == LevelError
+	})
+
+	if state := levels.Level(""); !state.ExpiresAt.IsZero() {
+		t.Fatalf("expected the reverted root level to be permanent, got %+v", state)
+	}
+
+	// SetBase keeps the temporary level until it expires.
+	levels.Set("", LevelDebug, 20*time.Millisecond)
+
+	if overridden := levels.SetBase(LevelError); !overridden {
+		t.Fatal("expected SetBase to report the temporary level")
+	}
+
+	if state := levels.Level(""); state.Level != LevelDebug {
+		t.Fatalf("expected the temporary level to be kept, got %q", state.Level)
+	}
+
+	waitFor(t, func() bool { return levels.Level("").Level == LevelError })
+
+	if overridden := levels.SetBase(LevelWarn); overridden || levels.Level("").Level != LevelWarn {
+		t.Fatalf("expected SetBase to apply the level at once, got %q", levels.Level("").Level)
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before timeout")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
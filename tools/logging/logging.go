@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
-	"os"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +30,15 @@ const (
 	LevelError LogLevel = "error"
 )
 
+// IsValid reports whether the level is one of the supported levels.
+func (l LogLevel) IsValid() bool {
+	switch l {
+	case LevelDebug, LevelInfo, LevelWarn, LevelError:
+		return true
+	}
+	return false
+}
+
 type LogFormat string
 
 const (
@@ -38,6 +46,11 @@ const (
 	FormatJson LogFormat = "json"
 )
 
+// IsValid reports whether the format is one of the supported formats.
+func (f LogFormat) IsValid() bool {
+	return f == FormatText || f == FormatJson
+}
+
 const (
 	DefaultLevel  LogLevel  = LevelInfo
 	DefaultFormat LogFormat = FormatText
@@ -49,6 +62,36 @@ type Config struct {
 	UseNano   bool
 	AddSource bool
 	Disabled  bool
+
+	// Levels changes the logger level at runtime. When set, it takes
+	// precedence over Level.
+	Levels *Levels
+
+	// Output, Rotation and Writer define where the logs are written,
+	// see SinkConfig. They are ignored when Sinks is set.
+	Output   string
+	Rotation RotationConfig
+	Writer   io.Writer
+
+	// Sinks writes the logs to several outputs, each with its own
+	// level and format.
+	Sinks []SinkConfig
+
+	// Files owns the log files, closed by the caller once the loggers are
+	// no longer used. The files are shared with the other loggers created
+	// with the same Files. When nil, the files stay open until the
+	// process exits.
+	Files *Files
+
+	// Handlers receive the records next to the sinks, e.g. a BatchHandler.
+	// Like the sinks with a level, they are only filtered by their own
+	// Enabled, independently of the logger levels. They are kept when
+	// Disabled is set.
+	Handlers []slog.Handler
+
+	// Redact masks the sensitive values. DefaultRedactConfig is used
+	// when nil; an empty config disables the redaction.
+	Redact *RedactConfig
 }
 
 func NewLogger() *slog.Logger {
@@ -60,7 +103,19 @@ func NewLogger() *slog.Logger {
 	})
 }
 
+// NewLoggerWithConfig is like OpenLogger but returns nil when the logger
+// cannot be created.
 func NewLoggerWithConfig(config Config) *slog.Logger {
+	logger, err := OpenLogger(config)
+	if err != nil {
+		return nil
+	}
+	return logger
+}
+
+// OpenLogger creates a logger writing to the sinks of the config. It fails
+// if a log file is already used with another rotation config, see Files.
+func OpenLogger(config Config) (*slog.Logger, error) {
 	if config.Level == "" {
 		config.Level = DefaultLevel
 	}
@@ -69,23 +124,56 @@ func NewLoggerWithConfig(config Config) *slog.Logger {
 		config.Format = DefaultFormat
 	}
 
-	options := &slog.HandlerOptions{
-		Level:       SlogLevel(config.Level),
-		AddSource:   config.AddSource,
-		ReplaceAttr: ReplaceAttr(config.UseNano),
+	if config.Levels == nil {
+		config.Levels = NewLevels(config.Level)
 	}
 
+	files := config.Files
+	if files == nil {
+		files = defaultFiles
+	}
+
+	if config.Disabled && len(config.Handlers) == 0 {
+		return slog.New(slog.NewTextHandler(io.Discard, nil)), nil
+	}
+
+	sinks := config.Sinks
 	if config.Disabled {
-		return slog.New(slog.NewTextHandler(io.Discard, nil))
+		sinks = nil
+	} else if len(sinks) == 0 {
+		sinks = []SinkConfig{{
+			Format:   config.Format,
+			Output:   config.Output,
+			Rotation: config.Rotation,
+			Writer:   config.Writer,
+		}}
 	}
 
-	logger := slog.New(slog.NewTextHandler(os.Stderr, options))
+	redact := DefaultRedactConfig()
+	if config.Redact != nil {
+		redact = *config.Redact
+	}
+
+	redactor := NewRedactor(redact)
+
+	// Each sink is filtered by the logger levels or by its own level, so a
+	// sink can receive the records below the logger level.
+	handlers := make([]slog.Handler, 0, len(sinks)+len(config.Handlers))
+	for _, sink := range sinks {
+		handler, err := sink.handler(config, files, redactor)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, handler)
+	}
+	handlers = append(handlers, config.Handlers...)
 
-	if config.Format == FormatJson {
-		logger = slog.New(slog.NewJSONHandler(os.Stderr, options))
+	handler := handlers[0]
+	if len(handlers) > 1 {
+		handler = &fanoutHandler{handlers: handlers}
 	}
 
-	return logger
+	return slog.New(&traceHandler{handler: handler}), nil
 }
 
 func DefaultLogger() *slog.Logger {
@@ -100,16 +188,28 @@ func LoggerWithContext(ctx context.Context, logger *slog.Logger) context.Context
 }
 
 func LoggerFromContext(ctx context.Context) *slog.Logger {
+	return LoggerFromContextOr(ctx, DefaultLogger())
+}
+
+// LoggerFromContextOr returns the context logger or fallback if none is set.
+func LoggerFromContextOr(ctx context.Context, fallback *slog.Logger) *slog.Logger {
 	if logger, ok := ctx.Value(loggerKey).(*slog.Logger); ok {
 		return logger
 	}
-	return DefaultLogger()
+	return fallback
 }
 
 type slogAttr func(groups []string, attr slog.Attr) slog.Attr
 
-func ReplaceAttr(useNano bool) slogAttr {
+// ReplaceAttr formats the built-in attributes and, when a redactor is
+// given, masks the sensitive values and messages.
+func ReplaceAttr(useNano bool, redactors ...*Redactor) slogAttr {
 	return func(_ []string, attr slog.Attr) slog.Attr {
+		for _, redactor := range redactors {
+			if attr.Key != slog.TimeKey && attr.Key != slog.LevelKey && attr.Key != slog.SourceKey {
+				attr = redactor.RedactAttr(attr)
+			}
+		}
 		if attr.Key == slog.TimeKey {
 			attr.Key = "time"
 			attr.Value = slog.StringValue(
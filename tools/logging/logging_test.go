@@ -110,6 +110,22 @@ func TestLoggerContext(t *testing.T) {
 	}
 }
 
+func TestLoggerContextFallback(t *testing.T) {
+	ctx := context.Background()
+	fallback := NewLoggerWithConfig(Config{Disabled: true})
+
+	if logger := LoggerFromContextOr(ctx, fallback); logger != fallback {
+		t.Errorf("expected logger %#v to be equal %#v", logger, fallback)
+	}
+
+	logger1 := NewLoggerWithConfig(Config{Disabled: true})
+	ctx = LoggerWithContext(ctx, logger1)
+
+	if logger2 := LoggerFromContextOr(ctx, fallback); logger2 != logger1 {
+		t.Errorf("expected logger %#v to be equal %#v", logger2, logger1)
+	}
+}
+
 func TestReplaceAttr(t *testing.T) {
 	tm := time.Now().UTC()
 	sr := &slog.Source{Function: "main.main", File: "/path/to/file", Line: 12}
@@ -238,3 +254,31 @@ func TestGetLogLevel(t *testing.T) {
 		})
 	}
 }
+
+func TestLogLevelIsValid(t *testing.T) {
+	for _, level := range []LogLevel{LevelDebug, LevelInfo, LevelWarn, LevelError} {
+		if !level.IsValid() {
+			t.Errorf("expected level %q to be valid", level)
+		}
+	}
+
+	for _, level := range []LogLevel{"", "verbose", "INFO"} {
+		if level.IsValid() {
+			t.Errorf("expected level %q to be invalid", level)
+		}
+	}
+}
+
+func TestLogFormatIsValid(t *testing.T) {
+	for _, format := range []LogFormat{FormatText, FormatJson} {
+		if !format.IsValid() {
+			t.Errorf("expected format %q to be valid", format)
+		}
+	}
+
+	for _, format := range []LogFormat{"", "xml"} {
+		if format.IsValid() {
+			t.Errorf("expected format %q to be invalid", format)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Outputs accepted by SinkConfig.Output besides a file path.
+const (
+	OutputStderr = "stderr"
+	OutputStdout = "stdout"
+)
+
+// RotationConfig defines how a file output is rotated. Zero values keep
+// the defaults: rotate at 100 megabytes and keep every rotated file.
+type RotationConfig struct {
+	// MaxSizeMB is the size in megabytes at which the file is rotated.
+	MaxSizeMB int
+
+	// MaxAgeDays removes the rotated files older than the given days.
+	MaxAgeDays int
+
+	// MaxBackups is the number of rotated files to keep.
+	MaxBackups int
+
+	// Compress gzips the rotated files.
+	Compress bool
+}
+
+// SinkConfig defines a log destination with its own level and format.
+type SinkConfig struct {
+	// Level is the minimum level of the sink, independent of the logger
+	// levels, e.g. to keep the debug logs in a file while the console only
+	// shows the warnings. Empty follows the logger levels, including their
+	// changes at runtime.
+	Level LogLevel
+
+	// Format defaults to the logger format.
+	Format LogFormat
+
+	// Output is stderr (default), stdout or a file path.
+	Output   string
+	Rotation RotationConfig
+
+	// Writer takes precedence over Output, e.g. to capture logs in tests.
+	Writer io.Writer
+}
+
+// Files owns the log files opened by the loggers. A file is shared by
+// every sink and logger writing to the same path, so rotation happens
+// once, and they must all use the same RotationConfig.
+type Files struct {
+	mu    sync.Mutex
+	files map[string]*rotatedFile
+}
+
+type rotatedFile struct {
+	*lumberjack.Logger
+	rotation RotationConfig
+}
+
+// NewFiles creates an empty set of log files.
+func NewFiles() *Files {
+	return &Files{files: make(map[string]*rotatedFile)}
+}
+
+// defaultFiles holds the files of the loggers created without Config.Files.
+// They stay open until the process exits.
+var defaultFiles = NewFiles()
+
+// open returns the file of the given path, opened on the first write. It
+// fails if the file is already used with another rotation config.
+func (f *Files) open(path string, rotation RotationConfig) (io.Writer, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if file, ok := f.files[path]; ok {
+		if file.rotation != rotation {
+			return nil, fmt.Errorf("log file %q is already used with another rotation config", path)
+		}
+		return file, nil
+	}
+
+	file := &rotatedFile{
+		Logger: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    rotation.MaxSizeMB,
+			MaxAge:     rotation.MaxAgeDays,
+			MaxBackups: rotation.MaxBackups,
+			Compress:   rotation.Compress,
+		},
+		rotation: rotation,
+	}
+
+	f.files[path] = file
+
+	return file, nil
+}
+
+// Close closes the files. The loggers still writing to them reopen them.
+func (f *Files) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var errs []error
+
+	for path, file := range f.files {
+		if err := file.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close log file %q: %w", path, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// output returns the writer of the sink.
+func (s SinkConfig) output(files *Files) (io.Writer, error) {
+	if s.Writer != nil {
+		return s.Writer, nil
+	}
+
+	switch s.Output {
+	case "", OutputStderr:
+		return os.Stderr, nil
+	case OutputStdout:
+		return os.Stdout, nil
+	}
+
+	return files.open(s.Output, s.Rotation)
+}
+
+// handler returns the handler of the sink. The sinks without a level of
+// their own are filtered by the logger levels.
+func (s SinkConfig) handler(config Config, files *Files, redactor *Redactor) (slog.Handler, error) {
+	w, err := s.output(files)
+	if err != nil {
+		return nil, err
+	}
+
+	level := slog.LevelDebug
+	if s.Level != "" {
+		level = SlogLevel(s.Level)
+	}
+
+	options := &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   config.AddSource,
+		ReplaceAttr: ReplaceAttr(config.UseNano, redactor),
+	}
+
+	format := s.Format
+	if format == "" {
+		format = config.Format
+	}
+
+	var handler slog.Handler
+
+	if format == FormatJson {
+		handler = slog.NewJSONHandler(w, options)
+	} else {
+		handler = slog.NewTextHandler(w, options)
+	}
+
+	if s.Level == "" {
+		handler = &levelHandler{handler: handler, levels: config.Levels}
+	}
+
+	return handler, nil
+}
+
+// fanoutHandler writes every record to each of its handlers.
+type fanoutHandler struct {
+	handlers []slog.Handler
+}
+
+func (h *fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *fanoutHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+
+	for _, handler := range h.handlers {
+		if !handler.Enabled(ctx, record.Level) {
+			continue
+		}
+
+		if err := handler.Handle(ctx, record.Clone()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
+
+func (h *fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &fanoutHandler{handlers: handlers}
+}
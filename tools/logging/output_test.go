@@ -0,0 +1,167 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLoggerWithWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	logger := NewLoggerWithConfig(Config{Format: FormatJson, Writer: buf})
+	logger.Info("test message", "key", "value")
+
+	expected := []string{`"level":"info"`, `"message":"test message"`, `"key":"value"`}
+
+	for _, content := range expected {
+		if !strings.Contains(buf.String(), content) {
+			t.Errorf("expected output to contain %q, got %q", content, buf.String())
+		}
+	}
+}
+
+func TestNewLoggerWithSinks(t *testing.T) {
+	debugBuf := new(bytes.Buffer)
+	infoBuf := new(bytes.Buffer)
+
+	logger := NewLoggerWithConfig(Config{
+		Level:  LevelDebug,
+		Format: FormatJson,
+		Sinks: []SinkConfig{
+			{Writer: debugBuf},
+			{Level: LevelInfo, Format: FormatText, Writer: infoBuf},
+		},
+	})
+
+	logger = logger.With("component", "sync").WithGroup("group")
+
+	logger.Debug("debug message", "key", "value")
+	logger.Info("info message")
+
+	if out := debugBuf.String(); !strings.Contains(out, `"message":"debug message"`) ||
+		!strings.Contains(out, `"message":"info message"`) ||
+		!strings.Contains(out, `"group":{"key":"value"}`) {
+		t.Fatalf("expected json sink to contain both messages, got %q", out)
+	}
+
+	if out := infoBuf.String(); strings.Contains(out, "debug message") ||
+		!strings.Contains(out, `message="info message" component=sync`) {
+		t.Fatalf("expected text sink to contain only the info message, got %q", out)
+	}
+}
+
+func TestNewLoggerWithSinkBelowLoggerLevel(t *testing.T) {
+	consoleBuf := new(bytes.Buffer)
+	fileBuf := new(bytes.Buffer)
+
+	levels := NewLevels(LevelWarn)
+
+	logger := NewLoggerWithConfig(Config{
+		Levels: levels,
+		Format: FormatJson,
+		Sinks: []SinkConfig{
+			{Writer: consoleBuf},
+			{Level: LevelDebug, Writer: fileBuf},
+		},
+	})
+
+	logger.Debug("debug message")
+	logger.Warn("warn message")
+
+	if out := fileBuf.String(); !strings.Contains(out, "debug message") || !strings.Contains(out, "warn message") {
+		t.Fatalf("expected the debug sink to contain both messages, got %q", out)
+	}
+
+	if out := consoleBuf.String(); strings.Contains(out, "debug message") || !strings.Contains(out, "warn message") {
+		t.Fatalf("expected the sink without level to follow the logger level, got %q", out)
+	}
+
+	// The sinks without level follow the runtime level changes.
+	levels.Set("", LevelDebug, 0)
+	logger.Debug("runtime debug message")
+
+	if out := consoleBuf.String(); !strings.Contains(out, "runtime debug message") {
+		t.Fatalf("expected the runtime level to apply to the sink, got %q", out)
+	}
+}
+
+func TestOpenLoggerRotationConflict(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinel.log")
+	files := NewFiles()
+
+	_, err := OpenLogger(Config{Output: path, Rotation: RotationConfig{MaxSizeMB: 1}, Files: files})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = OpenLogger(Config{Output: path, Rotation: RotationConfig{MaxSizeMB: 1}, Files: files})
+	if err != nil {
+		t.Fatalf("expected the same rotation config to share the file, got %v", err)
+	}
+
+	_, err = OpenLogger(Config{Output: path, Rotation: RotationConfig{MaxSizeMB: 2}, Files: files})
+	if err == nil || !strings.Contains(err.Error(), "another rotation config") {
+		t.Fatalf("expected a rotation config conflict, got %v", err)
+	}
+
+	_, err = OpenLogger(Config{
+		Sinks: []SinkConfig{
+			{Output: path + ".2"},
+			{Output: path + ".2", Rotation: RotationConfig{Compress: true}},
+		},
+		Files: files,
+	})
+	if err == nil {
+		t.Fatal("expected sinks of the same file with different rotation configs to fail")
+	}
+
+	if err := files.Close(); err != nil {
+		t.Fatalf("expected the files to be closed, got %v", err)
+	}
+}
+
+func TestNewLoggerWithFileOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sentinel.log")
+
+	files := NewFiles()
+	defer files.Close()
+
+	config := Config{
+		Format:   FormatJson,
+		Output:   path,
+		Rotation: RotationConfig{MaxSizeMB: 1, MaxBackups: 2},
+		Files:    files,
+	}
+
+	first := NewLoggerWithConfig(config)
+	second := NewLoggerWithConfig(config)
+
+	message := strings.Repeat("a", 1024)
+
+	// Write over 1 megabyte through two loggers sharing the file.
+	for range 600 {
+		first.Info(message)
+		second.Info(message)
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected the log file and one rotated file, got %d files", len(entries))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Size() > 1024*1024 {
+		t.Fatalf("expected log file to be rotated at 1MB, got %d bytes", info.Size())
+	}
+}
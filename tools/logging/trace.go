@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attribute keys of the trace and span ids added to the records logged
+// with a span context.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
+
+// TraceAttrs returns the trace and span id attributes of the given span.
+func TraceAttrs(span trace.SpanContext) []slog.Attr {
+	return []slog.Attr{
+		slog.String(TraceIDKey, span.TraceID().String()),
+		slog.String(SpanIDKey, span.SpanID().String()),
+	}
+}
+
+// ContextLogger returns a logger adding the trace and span ids of the ctx
+// span to its records, also when they are logged without a context, e.g.
+// with Info. The span of the context given to the log call wins, e.g.
+// with InfoContext. It returns the logger unchanged when it was not
+// created by this package.
+func ContextLogger(logger *slog.Logger, ctx context.Context) *slog.Logger {
+	h, ok := logger.Handler().(*traceHandler)
+	if !ok {
+		return logger
+	}
+
+	return slog.New(&traceHandler{handler: h.handler, hasTrace: h.hasTrace, ctx: ctx})
+}
+
+// traceHandler adds the trace and span ids of the context span, unless
+// the logger already carries them.
+type traceHandler struct {
+	handler  slog.Handler
+	hasTrace bool
+
+	// ctx is the fallback context of the log calls, see ContextLogger.
+	ctx context.Context
+}
+
+func (h *traceHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *traceHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.hasTrace {
+		span := trace.SpanContextFromContext(ctx)
+		if !span.IsValid() && h.ctx != nil {
+			span = trace.SpanContextFromContext(h.ctx)
+		}
+
+		if span.IsValid() {
+			record = record.Clone()
+			record.AddAttrs(TraceAttrs(span)...)
+		}
+	}
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *traceHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	hasTrace := h.hasTrace
+
+	for _, attr := range attrs {
+		if attr.Key == TraceIDKey {
+			hasTrace = true
+		}
+	}
+
+	return &traceHandler{handler: h.handler.WithAttrs(attrs), hasTrace: hasTrace, ctx: h.ctx}
+}
+
+func (h *traceHandler) WithGroup(name string) slog.Handler {
+	return &traceHandler{handler: h.handler.WithGroup(name), hasTrace: h.hasTrace, ctx: h.ctx}
+}
@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceIDs(t *testing.T) {
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	traceID := `"trace_id":"` + span.TraceID().String() + `"`
+	spanID := `"span_id":"` + span.SpanID().String() + `"`
+
+	testCases := []struct {
+		name     string
+		ctx      context.Context
+		attrs    []any
+		expected []string
+		excluded []string
+	}{
+		{"no span", context.Background(), nil, nil, []string{`"trace_id"`}},
+		{"span context", trace.ContextWithSpanContext(context.Background(), span), nil, []string{traceID, spanID}, nil},
+		{
+			"logger trace ids",
+			trace.ContextWithSpanContext(context.Background(), span),
+			[]any{TraceIDKey, "custom"},
+			[]string{`"trace_id":"custom"`},
+			[]string{traceID},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+
+			logger := NewLoggerWithConfig(Config{Format: FormatJson, Writer: buf}).With(tc.attrs...)
+			logger.InfoContext(tc.ctx, "test")
+
+			for _, content := range tc.expected {
+				if !strings.Contains(buf.String(), content) {
+					t.Errorf("expected content %v in log \n%v", content, buf.String())
+				}
+			}
+
+			for _, content := range tc.excluded {
+				if strings.Contains(buf.String(), content) {
+					t.Errorf("expected no content %v in log \n%v", content, buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestContextLogger(t *testing.T) {
+	span := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	other := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{3},
+		SpanID:     trace.SpanID{4},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	buf := new(bytes.Buffer)
+
+	logger := NewLoggerWithConfig(Config{Format: FormatJson, Writer: buf})
+	logger = ContextLogger(logger, trace.ContextWithSpanContext(context.Background(), span)).With("key", "value")
+
+	// Logged without a context, the ids of the logger context are added.
+	logger.Info("first")
+
+	if out := buf.String(); !strings.Contains(out, `"trace_id":"`+span.TraceID().String()+`"`) ||
+		strings.Count(out, `"trace_id"`) != 1 {
+		t.Fatalf("expected the logger context trace id once, got \n%v", out)
+	}
+
+	buf.Reset()
+
+	// The span of the log call context wins.
+	logger.InfoContext(trace.ContextWithSpanContext(context.Background(), other), "second")
+
+	if out := buf.String(); !strings.Contains(out, `"trace_id":"`+other.TraceID().String()+`"`) {
+		t.Fatalf("expected the call context trace id, got \n%v", out)
+	}
+}
@@ -0,0 +1,411 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the Prometheus text exposition format content type.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the default histogram buckets, in seconds, tailored
+// to measure http request latencies.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// labelSeparator joins label values into a series key.
+const labelSeparator = "\xff"
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+type collector interface {
+	// names returns every metric name written by the collector.
+	names() []string
+	write(w *bufio.Writer)
+}
+
+// Registry holds a set of metric families and renders them in the
+// Prometheus text exposition format.
+type Registry struct {
+	mu         sync.RWMutex
+	names      map[string]struct{}
+	collectors []collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		names: make(map[string]struct{}),
+	}
+}
+
+// NewCounter registers a new monotonically increasing counter.
+// It panics if a metric with the same name is already registered, or if
+// the name or the labels are invalid.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{family: newFamily(name, help, "counter", labels)}
+	r.register(c)
+	return c
+}
+
+// NewGauge registers a new gauge.
+// It panics if a metric with the same name is already registered, or if
+// the name or the labels are invalid.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{family: newFamily(name, help, "gauge", labels)}
+	r.register(g)
+	return g
+}
+
+// NewGaugeFunc registers a gauge whose value is read from fn on every scrape.
+// It panics if a metric with the same name is already registered, or if
+// the name is invalid.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&gaugeFunc{family: newFamily(name, help, "gauge", nil), fn: fn})
+}
+
+// NewHistogram registers a new histogram. DefaultBuckets are used when
+// buckets is empty. It panics if a metric with the same name, or one of
+// its `_bucket`, `_sum` and `_count` series, is already registered, or if
+// the name or the labels are invalid.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	h := &Histogram{family: newFamily(name, help, "histogram", labels), buckets: buckets}
+	r.register(h)
+	return h
+}
+
+// WriteText writes every registered metric in the Prometheus text format.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.RLock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.RUnlock()
+
+	bw := bufio.NewWriter(w)
+
+	for _, c := range collectors {
+		c.write(bw)
+	}
+
+	return bw.Flush()
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := c.names()
+
+	for _, name := range names {
+		if _, ok := r.names[name]; ok {
+			panic(fmt.Sprintf("metrics: duplicated metric name %q", name))
+		}
+	}
+
+	for _, name := range names {
+		r.names[name] = struct{}{}
+	}
+
+	r.collectors = append(r.collectors, c)
+}
+
+// family holds the metadata and series shared by every metric type.
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+type series struct {
+	labelValues []string
+	value       float64
+
+	// Histogram only fields.
+	counts []uint64
+	count  uint64
+}
+
+func newFamily(name, help, kind string, labels []string) *family {
+	if !metricNameRegex.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+
+	for i, label := range labels {
+		switch {
+		case !labelNameRegex.MatchString(label) || strings.HasPrefix(label, "__"):
+			panic(fmt.Sprintf("metrics: %q has an invalid label name %q", name, label))
+		case kind == "histogram" && label == "le":
+			panic(fmt.Sprintf("metrics: %q cannot use the reserved histogram label %q", name, label))
+		case slices.Contains(labels[:i], label):
+			panic(fmt.Sprintf("metrics: %q has a duplicated label %q", name, label))
+		}
+	}
+
+	f := &family{
+		name:   name,
+		help:   help,
+		kind:   kind,
+		labels: labels,
+		series: make(map[string]*series),
+	}
+
+	// Metrics without labels are exposed with their zero value right away.
+	if len(labels) == 0 && kind != "histogram" {
+		f.get(nil)
+	}
+
+	return f
+}
+
+// names returns the metric names written by the family.
+func (f *family) names() []string {
+	if f.kind == "histogram" {
+		return []string{f.name, f.name + "_bucket", f.name + "_sum", f.name + "_count"}
+	}
+	return []string{f.name}
+}
+
+// get returns the series for the given label values. It must be called
+// with the family lock held.
+func (f *family) get(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf(
+			"metrics: %q expects %d label values, got %d",
+			f.name, len(f.labels), len(labelValues),
+		))
+	}
+
+	key := strings.Join(labelValues, labelSeparator)
+
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labelValues: slices.Clone(labelValues)}
+		f.series[key] = s
+	}
+
+	return s
+}
+
+// sorted returns the family series ordered by their label values.
+// It must be called with the family lock held.
+func (f *family) sorted() []*series {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	result := make([]*series, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, f.series[key])
+	}
+
+	return result
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// Counter is a monotonically increasing metric.
+type Counter struct {
+	*family
+}
+
+// Inc increments the counter series identified by labelValues by 1.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter series identified by labelValues by v.
+// Negative values are ignored.
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	c.get(labelValues).value += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+
+	for _, s := range c.sorted() {
+		writeSample(w, c.name, c.labels, s.labelValues, nil, s.value)
+	}
+}
+
+// Gauge is a metric that can arbitrarily go up and down.
+type Gauge struct {
+	*family
+}
+
+// Set sets the gauge series identified by labelValues to v.
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = v
+	g.mu.Unlock()
+}
+
+// Add adds v (which can be negative) to the gauge series identified by labelValues.
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += v
+	g.mu.Unlock()
+}
+
+// Inc increments the gauge series identified by labelValues by 1.
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec decrements the gauge series identified by labelValues by 1.
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(w)
+
+	for _, s := range g.sorted() {
+		writeSample(w, g.name, g.labels, s.labelValues, nil, s.value)
+	}
+}
+
+type gaugeFunc struct {
+	*family
+	fn func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.writeHeader(w)
+	writeSample(w, g.name, nil, nil, nil, g.fn())
+}
+
+// Histogram counts observations into configurable buckets.
+type Histogram struct {
+	*family
+	buckets []float64
+}
+
+// Observe adds a single observation to the histogram series identified by labelValues.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.get(labelValues)
+	if s.counts == nil {
+		s.counts = make([]uint64, len(h.buckets))
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			s.counts[i]++
+		}
+	}
+
+	s.count++
+	s.value += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+
+	for _, s := range h.sorted() {
+		for i, bound := range h.buckets {
+			le := [2]string{"le", formatFloat(bound)}
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, &le, float64(s.counts[i]))
+		}
+
+		le := [2]string{"le", "+Inf"}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, &le, float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, nil, s.value)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, nil, float64(s.count))
+	}
+}
+
+func writeSample(
+	w *bufio.Writer,
+	name string,
+	labels []string,
+	labelValues []string,
+	extra *[2]string,
+	value float64,
+) {
+	_, _ = w.WriteString(name)
+
+	if len(labels) > 0 || extra != nil {
+		_ = w.WriteByte('{')
+
+		for i, label := range labels {
+			if i > 0 {
+				_ = w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, label, escapeLabel(labelValues[i]))
+		}
+
+		if extra != nil {
+			if len(labels) > 0 {
+				_ = w.WriteByte(',')
+			}
+			fmt.Fprintf(w, `%s="%s"`, extra[0], extra[1])
+		}
+
+		_ = w.WriteByte('}')
+	}
+
+	_ = w.WriteByte(' ')
+	_, _ = w.WriteString(formatFloat(value))
+	_ = w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+
+	counter := r.NewCounter("test_requests_total", "Total requests.", "method", "status")
+	counter.Inc("GET", "200")
+	counter.Inc("GET", "200")
+	counter.Add(3, "POST", "500")
+	counter.Add(-1, "POST", "500")
+
+	gauge := r.NewGauge("test_in_flight", "In flight requests.")
+	gauge.Inc()
+	gauge.Inc()
+	gauge.Dec()
+
+	r.NewGaugeFunc("test_answer", "The answer.", func() float64 { return 42 })
+
+	histogram := r.NewHistogram("test_duration_seconds", "Durations.", []float64{1, 0.1}, "route")
+	histogram.Observe(0.05, `/a"b`)
+	histogram.Observe(0.5, `/a"b`)
+	histogram.Observe(5, `/a"b`)
+
+	buf := new(bytes.Buffer)
+	if err := r.WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"# HELP test_requests_total Total requests.\n",
+		"# TYPE test_requests_total counter\n",
+		`test_requests_total{method="GET",status="200"} 2` + "\n",
+		`test_requests_total{method="POST",status="500"} 3` + "\n",
+		"# TYPE test_in_flight gauge\n",
+		"test_in_flight 1\n",
+		"test_answer 42\n",
+		"# TYPE test_duration_seconds histogram\n",
+		`test_duration_seconds_bucket{route="/a\"b",le="0.1"} 1` + "\n",
+		`test_duration_seconds_bucket{route="/a\"b",le="1"} 2` + "\n",
+		`test_duration_seconds_bucket{route="/a\"b",le="+Inf"} 3` + "\n",
+		`test_duration_seconds_sum{route="/a\"b"} 5.55` + "\n",
+		`test_duration_seconds_count{route="/a\"b"} 3` + "\n",
+	}
+
+	body := buf.String()
+
+	for _, content := range expected {
+		if !strings.Contains(body, content) {
+			t.Errorf("expected content %q in metrics output \n%v", content, body)
+		}
+	}
+}
+
+func TestRegistryDuplicatedName(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.NewCounter("test_total", "Test.")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected duplicated metric name to panic")
+		}
+	}()
+
+	r.NewGauge("test_total", "Test.")
+}
+
+func TestRegistryInvalidMetrics(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"invalid metric name", func(r *Registry) { r.NewCounter("test-total", "Test.") }},
+		{"metric name starting with a digit", func(r *Registry) { r.NewGauge("1_test", "Test.") }},
+		{"invalid label name", func(r *Registry) { r.NewCounter("test_total", "Test.", "http.method") }},
+		{"reserved label name", func(r *Registry) { r.NewCounter("test_total", "Test.", "__name") }},
+		{"histogram le label", func(r *Registry) { r.NewHistogram("test_seconds", "Test.", nil, "le") }},
+		{"duplicated label", func(r *Registry) { r.NewGauge("test", "Test.", "route", "route") }},
+		{"histogram series collision", func(r *Registry) {
+			r.NewHistogram("test_seconds", "Test.", nil)
+			r.NewCounter("test_seconds_count", "Test.")
+		}},
+		{"runtime metric name", func(r *Registry) {
+			r.RegisterRuntimeMetrics()
+			r.NewGauge("go_goroutines", "Test.")
+		}},
+		{"runtime metric registered after", func(r *Registry) {
+			r.NewGauge("process_start_time_seconds", "Test.")
+			r.RegisterRuntimeMetrics()
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected %s to panic", tc.name)
+				}
+			}()
+
+			tc.register(NewRegistry())
+		})
+	}
+}
+
+func TestRegistryLabelMismatch(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	counter := r.NewCounter("test_total", "Test.", "method")
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected label values mismatch to panic")
+		}
+	}()
+
+	counter.Inc()
+}
+
+func TestRegisterRuntimeMetrics(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.RegisterRuntimeMetrics()
+
+	buf := new(bytes.Buffer)
+	if err := r.WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	body := buf.String()
+
+	for _, name := range []string{"go_info{version=", "go_goroutines ", "go_memstats_alloc_bytes ", "process_start_time_seconds "} {
+		if !strings.Contains(body, name) {
+			t.Errorf("expected metric %q in metrics output \n%v", name, body)
+		}
+	}
+}
+
+func TestRegistryWriteTextEscaping(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	r.RegisterRuntimeMetrics()
+	r.NewCounter("test_requests_total", "Total \\ requests\nsent.", "method").Inc("GET\n")
+	r.NewHistogram("test_duration_seconds", "Durations.", nil, "route").Observe(0.2, `/a"b\c`)
+
+	buf := new(bytes.Buffer)
+	if err := r.WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	output := buf.String()
+
+	expected := []string{
+		"# HELP test_requests_total Total \\\\ requests\\nsent.\n",
+		"test_requests_total{method=\"GET\\n\"} 1\n",
+		"test_duration_seconds_sum{route=\"/a\\\"b\\\\c\"} 0.2\n",
+		"test_duration_seconds_count{route=\"/a\\\"b\\\\c\"} 1\n",
+	}
+
+	for _, name := range new(runtimeCollector).names() {
+		expected = append(expected, "# TYPE "+name+" ")
+	}
+
+	for _, expected := range expected {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected output to contain %q, got \n%v", expected, output)
+		}
+	}
+
+	// Every sample line is a metric name, optional labels and a value.
+	for line := range strings.SplitSeq(strings.TrimSpace(output), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			continue
+		}
+
+		if i := strings.LastIndexByte(line, ' '); i < 1 || !metricNameRegex.MatchString(line[:strings.IndexAny(line, "{ ")]) {
+			t.Errorf("expected a valid sample line, got %q", line)
+		}
+	}
+}
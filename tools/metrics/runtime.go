@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"bufio"
+	"runtime"
+	"time"
+)
+
+// runtimeMetric is a Go runtime or process metric read on every scrape.
+type runtimeMetric struct {
+	name  string
+	help  string
+	kind  string
+	value func(c *runtimeCollector, stats *runtime.MemStats) float64
+}
+
+// runtimeMetrics lists the metrics written by RegisterRuntimeMetrics next
+// to `go_info`. Their names are reserved in the registry.
+var runtimeMetrics = []runtimeMetric{
+	{"go_goroutines", "Number of goroutines that currently exist.", "gauge", func(_ *runtimeCollector, _ *runtime.MemStats) float64 {
+		return float64(runtime.NumGoroutine())
+	}},
+	{"go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", "gauge", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.Alloc)
+	}},
+	{"go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", "counter", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.TotalAlloc)
+	}},
+	{"go_memstats_sys_bytes", "Number of bytes obtained from system.", "gauge", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.Sys)
+	}},
+	{"go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", "gauge", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.HeapInuse)
+	}},
+	{"go_memstats_heap_objects", "Number of allocated objects.", "gauge", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.HeapObjects)
+	}},
+	{"go_gc_cycles_total", "Number of completed GC cycles.", "counter", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.NumGC)
+	}},
+	{"go_gc_pause_seconds_total", "Total GC stop-the-world pause time in seconds.", "counter", func(_ *runtimeCollector, s *runtime.MemStats) float64 {
+		return float64(s.PauseTotalNs) / 1e9
+	}},
+	{"process_start_time_seconds", "Start time of the process since unix epoch in seconds.", "gauge", func(c *runtimeCollector, _ *runtime.MemStats) float64 {
+		return float64(c.startTime.Unix())
+	}},
+}
+
+// RegisterRuntimeMetrics registers the Go runtime and process metrics.
+// It panics if called more than once for the same registry, or if one of
+// the runtime metric names is already registered.
+func (r *Registry) RegisterRuntimeMetrics() {
+	r.register(&runtimeCollector{startTime: time.Now()})
+}
+
+// runtimeCollector reads the runtime stats once per scrape.
+type runtimeCollector struct {
+	startTime time.Time
+}
+
+func (c *runtimeCollector) names() []string {
+	names := []string{"go_info"}
+	for _, m := range runtimeMetrics {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+func (c *runtimeCollector) write(w *bufio.Writer) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	info := newFamily("go_info", "Information about the Go environment.", "gauge", []string{"version"})
+	info.writeHeader(w)
+	writeSample(w, info.name, info.labels, []string{runtime.Version()}, nil, 1)
+
+	for _, m := range runtimeMetrics {
+		f := newFamily(m.name, m.help, m.kind, nil)
+		f.writeHeader(w)
+		writeSample(w, f.name, nil, nil, nil, m.value(c, &stats))
+	}
+}
@@ -0,0 +1,26 @@
+package registry
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseDuration parses a Go duration string, e.g. `30s`, `1m30s` or
+// `250ms`. For backward compatibility a bare integer is read as a number
+// of the given unit, e.g. `5` with time.Second is 5 seconds.
+func ParseDuration(value string, unit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,41 @@
+package registry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		value    string
+		unit     time.Duration
+		expected time.Duration
+		hasError bool
+	}{
+		{"30s", time.Second, 30 * time.Second, false},
+		{"1m30s", time.Second, 90 * time.Second, false},
+		{"250ms", time.Second, 250 * time.Millisecond, false},
+		{" 5 ", time.Second, 5 * time.Second, false},
+		{"5", time.Minute, 5 * time.Minute, false},
+		{"0", time.Second, 0, false},
+		{"-1s", time.Second, -time.Second, false},
+		{"", time.Second, 0, true},
+		{"abc", time.Second, 0, true},
+		{"5 seconds", time.Second, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			d, err := ParseDuration(tc.value, tc.unit)
+
+			hasError := err != nil
+			if hasError != tc.hasError {
+				t.Fatalf("expected hasError to be %v, got %v (%v)", tc.hasError, hasError, err)
+			}
+
+			if d != tc.expected {
+				t.Fatalf("expected duration to be %v, got %v", tc.expected, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// watchDebounce groups the burst of events emitted by editors and config
+// map updates into a single change notification.
+const watchDebounce = 100 * time.Millisecond
+
+// Source identifies the configuration layer a value was read from.
+type Source string
+
+// Configuration sources, from the lowest to the highest precedence.
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceDotEnv  Source = "dotenv"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+)
+
+// LayeredConfig defines a Layered registry configuration option.
+type LayeredConfig struct {
+	// ConfigFile is an optional yaml or toml config file path. The file
+	// type is inferred from its extension and, unlike the .env file,
+	// it must exist when set.
+	ConfigFile string
+
+	// DotEnvPath and DotEnvName locate the optional .env file.
+	DotEnvPath string
+	DotEnvName string
+
+	// EnvPrefix is prepended to the keys when reading env variables,
+	// e.g. `SENTINEL` reads the `LOG_LEVEL` key from `SENTINEL_LOG_LEVEL`.
+	EnvPrefix string
+}
+
+// Layered resolves configuration keys with a fixed precedence:
+// defaults < config file < .env < env < flags.
+//
+// Keys are flat and case insensitive, e.g. `LOG_LEVEL` matches the
+// `log_level` key of a yaml config file.
+type Layered struct {
+	config    LayeredConfig
+	envPrefix string
+
+	mu     sync.RWMutex
+	file   *viper.Viper
+	dotenv *viper.Viper
+	flags  map[string]string
+}
+
+func NewLayered(config LayeredConfig) (*Layered, error) {
+	if config.DotEnvPath == "" {
+		config.DotEnvPath = defaultConfigPath
+	}
+
+	if config.DotEnvName == "" {
+		config.DotEnvName = defaultConfigName
+	}
+
+	l := &Layered{
+		config:    config,
+		envPrefix: strings.TrimSpace(config.EnvPrefix),
+		flags:     make(map[string]string),
+	}
+
+	if err := l.Reload(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Reload reads the config file and the .env file again. The previous
+// values are kept when any of them fails to load.
+func (l *Layered) Reload() error {
+	var file *viper.Viper
+
+	if l.config.ConfigFile != "" {
+		file = viper.New()
+		file.SetConfigFile(l.config.ConfigFile)
+		file.SetConfigType(strings.TrimPrefix(filepath.Ext(l.config.ConfigFile), "."))
+
+		if err := file.ReadInConfig(); err != nil {
+			return err
+		}
+	}
+
+	dotenv := viper.New()
+	dotenv.AddConfigPath(l.config.DotEnvPath)
+	dotenv.SetConfigType(defaultConfigType)
+	dotenv.SetConfigName(l.config.DotEnvName)
+
+	if err := dotenv.ReadInConfig(); err != nil {
+		// The .env file is optional.
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return err
+		}
+	}
+
+	l.mu.Lock()
+	l.file = file
+	l.dotenv = dotenv
+	l.mu.Unlock()
+
+	return nil
+}
+
+// kubernetesDataDir is the symlink swapped by the kubelet when a mounted
+// ConfigMap or Secret is updated.
+const kubernetesDataDir = "..data"
+
+// Watch calls onChange every time the config file or the .env file is
+// written, created, renamed or removed, until ctx is done. It does not
+// reload the files, which is left to onChange through Reload.
+//
+// The files mounted from a Kubernetes ConfigMap or Secret are symlinks
+// to a `..data` symlink, swapped to a new directory on every update.
+// Since no event names the files then, the changes of `..data` in their
+// directories are notified as well.
+func (l *Layered) Watch(ctx context.Context, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+
+	paths := []string{filepath.Join(l.config.DotEnvPath, l.config.DotEnvName)}
+	if l.config.ConfigFile != "" {
+		paths = append(paths, l.config.ConfigFile)
+	}
+
+	for _, path := range paths {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			_ = watcher.Close()
+			return err
+		}
+
+		files[path] = true
+
+		// Directories are watched rather than the files themselves so
+		// the files replaced by a rename, or created later, are detected.
+		// The watch is not recursive, the files changed through a symlink
+		// are only detected for the `..data` layout of Kubernetes.
+		dir := filepath.Dir(path)
+		if dirs[dir] {
+			continue
+		}
+
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return err
+		}
+
+		dirs[dir] = true
+	}
+
+	go func() {
+		defer func() { _ = watcher.Close() }()
+
+		var timer *time.Timer
+
+		for {
+			select {
+			case <-ctx.Done():
+				if timer != nil {
+					timer.Stop()
+				}
+				return
+			case e, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				path, err := filepath.Abs(e.Name)
+				if err != nil || e.Op == fsnotify.Chmod {
+					continue
+				}
+
+				swapped := filepath.Base(path) == kubernetesDataDir && dirs[filepath.Dir(path)]
+				if !files[path] && !swapped {
+					continue
+				}
+
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDebounce, onChange)
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// SetFlag sets a value with the flag precedence, e.g. from a command flag.
+func (l *Layered) SetFlag(key string, value string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.flags[strings.ToUpper(key)] = value
+}
+
+// Lookup returns the value of the given key from the highest precedence
+// layer where it is set, and that layer source. It returns false when
+// the key is not set in any layer.
+func (l *Layered) Lookup(key string) (string, Source, bool) {
+	return l.LookupWithAliases(key)
+}
+
+// LookupWithAliases is like Lookup but also reads the given alias keys,
+// e.g. the former name of a renamed setting. The highest precedence
+// layer wins, and within a layer the key wins over its aliases.
+func (l *Layered) LookupWithAliases(key string, aliases ...string) (string, Source, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	keys := make([]string, 0, len(aliases)+1)
+	for _, k := range append([]string{key}, aliases...) {
+		keys = append(keys, strings.ToUpper(k))
+	}
+
+	for _, key := range keys {
+		if value, ok := l.flags[key]; ok {
+			return value, SourceFlag, true
+		}
+	}
+
+	for _, key := range keys {
+		envKey := key
+		if l.envPrefix != "" {
+			envKey = strings.ToUpper(l.envPrefix) + "_" + key
+		}
+
+		if value, ok := os.LookupEnv(envKey); ok {
+			return value, SourceEnv, true
+		}
+	}
+
+	for _, key := range keys {
+		if l.dotenv.IsSet(key) {
+			return l.dotenv.GetString(key), SourceDotEnv, true
+		}
+	}
+
+	for _, key := range keys {
+		if l.file != nil && l.file.IsSet(key) {
+			return l.file.GetString(key), SourceFile, true
+		}
+	}
+
+	return "", SourceDefault, false
+}
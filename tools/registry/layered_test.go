@@ -0,0 +1,289 @@
+package registry
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLayered(t *testing.T) {
+	l, err := NewLayered(LayeredConfig{
+		ConfigFile: "../../tests/config/test_layered.yaml",
+		DotEnvPath: "../../tests/config",
+		DotEnvName: "test_config",
+		EnvPrefix:  "LAYERED",
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	t.Setenv("LAYERED_TEST_PORT", "9100")
+
+	l.SetFlag("test_flag_only", "flag")
+
+	testCases := []struct {
+		key            string
+		expectedValue  string
+		expectedSource Source
+		expectedOk     bool
+	}{
+		{"TEST_FILE_ONLY", "true", SourceFile, true},
+		{"TEST_GREETING", "hello", SourceDotEnv, true},
+		{"test_greeting", "hello", SourceDotEnv, true},
+		{"TEST_PORT", "9100", SourceEnv, true},
+		{"TEST_FLAG_ONLY", "flag", SourceFlag, true},
+		{"TEST_MISSING", "", SourceDefault, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			value, source, ok := l.Lookup(tc.key)
+
+			if value != tc.expectedValue || source != tc.expectedSource || ok != tc.expectedOk {
+				t.Fatalf(
+					"expected (%q, %q, %v), got (%q, %q, %v)",
+					tc.expectedValue, tc.expectedSource, tc.expectedOk,
+					value, source, ok,
+				)
+			}
+		})
+	}
+
+	// Flags take precedence over every other layer.
+	l.SetFlag("TEST_PORT", "9200")
+
+	if value, source, _ := l.Lookup("TEST_PORT"); value != "9200" || source != SourceFlag {
+		t.Fatalf("expected flag value 9200, got %q from %q", value, source)
+	}
+}
+
+func TestNewLayeredToml(t *testing.T) {
+	l, err := NewLayered(LayeredConfig{
+		ConfigFile: "../../tests/config/test_layered.toml",
+		DotEnvPath: "../../tests/config",
+		DotEnvName: "test_empty",
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	if value, source, _ := l.Lookup("TEST_GREETING"); value != "toml" || source != SourceFile {
+		t.Fatalf("expected file value toml, got %q from %q", value, source)
+	}
+}
+
+func TestNewLayeredMissingFiles(t *testing.T) {
+	// A missing .env file is not an error.
+	l, err := NewLayered(LayeredConfig{DotEnvPath: "../../tests/config", DotEnvName: "missing"})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	if _, _, ok := l.Lookup("TEST_GREETING"); ok {
+		t.Fatal("expected TEST_GREETING not to be set")
+	}
+
+	// A missing config file is.
+	_, err = NewLayered(LayeredConfig{ConfigFile: "../../tests/config/missing.yaml"})
+	if err == nil || !strings.Contains(err.Error(), "missing.yaml") {
+		t.Fatalf("expected missing config file error, got %v", err)
+	}
+}
+
+func TestLayeredLookupWithAliases(t *testing.T) {
+	l, err := NewLayered(LayeredConfig{
+		ConfigFile: "../../tests/config/test_layered.yaml",
+		DotEnvPath: "../../tests/config",
+		DotEnvName: "test_empty",
+		EnvPrefix:  "LAYERED",
+	})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	if _, _, ok := l.LookupWithAliases("TEST_TIMEOUT", "TEST_TIMEOUT_SECS"); ok {
+		t.Fatal("expected TEST_TIMEOUT not to be set")
+	}
+
+	// The alias is read when the key is not set.
+	t.Setenv("LAYERED_TEST_TIMEOUT_SECS", "5")
+
+	if value, source, _ := l.LookupWithAliases("TEST_TIMEOUT", "TEST_TIMEOUT_SECS"); value != "5" || source != SourceEnv {
+		t.Fatalf("expected env value 5, got %q from %q", value, source)
+	}
+
+	// The key wins over the alias in the same layer.
+	t.Setenv("LAYERED_TEST_TIMEOUT", "250ms")
+
+	if value, source, _ := l.LookupWithAliases("TEST_TIMEOUT", "TEST_TIMEOUT_SECS"); value != "250ms" || source != SourceEnv {
+		t.Fatalf("expected env value 250ms, got %q from %q", value, source)
+	}
+
+	// A higher precedence layer wins over the key.
+	l.SetFlag("TEST_TIMEOUT_SECS", "10")
+
+	if value, source, _ := l.LookupWithAliases("TEST_TIMEOUT", "TEST_TIMEOUT_SECS"); value != "10" || source != SourceFlag {
+		t.Fatalf("expected flag value 10, got %q from %q", value, source)
+	}
+}
+
+func TestLayeredReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, file, "test_greeting: before\n")
+	writeFile(t, filepath.Join(dir, ".env"), "TEST_PORT=8090\n")
+
+	l, err := NewLayered(LayeredConfig{ConfigFile: file, DotEnvPath: dir})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	writeFile(t, file, "test_greeting: after\n")
+
+	if err := os.Remove(filepath.Join(dir, ".env")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := l.Reload(); err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	if value, _, _ := l.Lookup("TEST_GREETING"); value != "after" {
+		t.Fatalf("expected reloaded value after, got %q", value)
+	}
+
+	if _, _, ok := l.Lookup("TEST_PORT"); ok {
+		t.Fatal("expected TEST_PORT of the removed .env file not to be set")
+	}
+
+	// The previous values are kept when the config file is invalid.
+	writeFile(t, file, "test_greeting: [\n")
+
+	if err := l.Reload(); err == nil {
+		t.Fatal("expected reload error, got nil")
+	}
+
+	if value, _, _ := l.Lookup("TEST_GREETING"); value != "after" {
+		t.Fatalf("expected previous value after, got %q", value)
+	}
+}
+
+func TestLayeredWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	writeFile(t, file, "test_greeting: before\n")
+
+	l, err := NewLayered(LayeredConfig{ConfigFile: file, DotEnvPath: dir})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 10)
+
+	if err := l.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	// Unrelated files in the same directory are ignored.
+	writeFile(t, filepath.Join(dir, "other.yaml"), "test_greeting: other\n")
+
+	select {
+	case <-changes:
+		t.Fatal("expected unrelated file change to be ignored")
+	case <-time.After(3 * watchDebounce):
+	}
+
+	// The .env file is watched even when created after Watch.
+	writeFile(t, filepath.Join(dir, ".env"), "TEST_PORT=8090\n")
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected .env file change to be notified")
+	}
+
+	writeFile(t, file, "test_greeting: after\n")
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected config file change to be notified")
+	}
+}
+
+func TestLayeredWatchKubernetesConfigMap(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+
+	// The layout of a mounted ConfigMap, where config.yaml links to
+	// ..data/config.yaml and ..data to a timestamped directory.
+	mountVersion := func(version string, content string) {
+		t.Helper()
+
+		if err := os.Mkdir(filepath.Join(dir, version), 0o700); err != nil {
+			t.Fatal(err)
+		}
+
+		writeFile(t, filepath.Join(dir, version, "config.yaml"), content)
+
+		if err := os.Symlink(version, filepath.Join(dir, "..data_tmp")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mountVersion("..2026_01_01", "test_greeting: before\n")
+
+	if err := os.Symlink(filepath.Join("..data", "config.yaml"), file); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := NewLayered(LayeredConfig{ConfigFile: file, DotEnvPath: dir})
+	if err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 10)
+
+	if err := l.Watch(ctx, func() { changes <- struct{}{} }); err != nil {
+		t.Fatalf("expected error to be nil, got %v", err)
+	}
+
+	mountVersion("..2026_01_02", "test_greeting: after\n")
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the ConfigMap update to be notified")
+	}
+
+	if err := l.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	if value, _, _ := l.Lookup("TEST_GREETING"); value != "after" {
+		t.Fatalf("expected the updated value, got %q", value)
+	}
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	metricNameRegex = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	labelNameRegex  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	durationRegex   = regexp.MustCompile(`^(\d+y)?(\d+w)?(\d+d)?(\d+h)?(\d+m)?(\d+s)?(\d+ms)?$`)
+)
+
+// File defines a Prometheus rule file.
+type File struct {
+	Groups []Group `yaml:"groups"`
+}
+
+// Group defines a Prometheus rule group.
+type Group struct {
+	Name     string `yaml:"name"`
+	Interval string `yaml:"interval,omitempty"`
+	Limit    int    `yaml:"limit,omitempty"`
+	Rules    []Rule `yaml:"rules"`
+}
+
+// Rule defines a Prometheus alerting or recording rule.
+type Rule struct {
+	Record        string            `yaml:"record,omitempty"`
+	Alert         string            `yaml:"alert,omitempty"`
+	Expr          string            `yaml:"expr"`
+	For           string            `yaml:"for,omitempty"`
+	KeepFiringFor string            `yaml:"keep_firing_for,omitempty"`
+	Labels        map[string]string `yaml:"labels,omitempty"`
+	Annotations   map[string]string `yaml:"annotations,omitempty"`
+}
+
+// Parse decodes a rule file content, rejecting unknown fields.
+func Parse(content []byte) (*File, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	file := &File{}
+
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// ParseFile reads and decodes the rule file at the given path.
+func ParseFile(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+// Validate checks the rule file structure and returns an error listing
+// every problem found. PromQL expressions are only checked for presence.
+func (f *File) Validate() error {
+	var errs []error
+
+	if len(f.Groups) == 0 {
+		errs = append(errs, errors.New("no rule groups defined"))
+	}
+
+	names := make(map[string]struct{}, len(f.Groups))
+
+	for i, group := range f.Groups {
+		prefix := fmt.Sprintf("group %d", i)
+		if group.Name != "" {
+			prefix = fmt.Sprintf("group %q", group.Name)
+		}
+
+		if strings.TrimSpace(group.Name) == "" {
+			errs = append(errs, fmt.Errorf("%s: name is required", prefix))
+		} else if _, ok := names[group.Name]; ok {
+			errs = append(errs, fmt.Errorf("%s: name is duplicated", prefix))
+		}
+
+		names[group.Name] = struct{}{}
+
+		if group.Interval != "" && !isDuration(group.Interval) {
+			errs = append(errs, fmt.Errorf("%s: invalid interval %q", prefix, group.Interval))
+		}
+
+		if group.Limit < 0 {
+			errs = append(errs, fmt.Errorf("%s: limit cannot be negative", prefix))
+		}
+
+		if len(group.Rules) == 0 {
+			errs = append(errs, fmt.Errorf("%s: no rules defined", prefix))
+		}
+
+		for j, rule := range group.Rules {
+			for _, err := range rule.validate() {
+				errs = append(errs, fmt.Errorf("%s: rule %d: %w", prefix, j, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (r *Rule) validate() []error {
+	var errs []error
+
+	switch {
+	case r.Record == "" && r.Alert == "":
+		errs = append(errs, errors.New("one of record or alert is required"))
+	case r.Record != "" && r.Alert != "":
+		errs = append(errs, errors.New("only one of record or alert can be set"))
+	case r.Record != "" && !metricNameRegex.MatchString(r.Record):
+		errs = append(errs, fmt.Errorf("invalid record name %q", r.Record))
+	}
+
+	if strings.TrimSpace(r.Expr) == "" {
+		errs = append(errs, errors.New("expr is required"))
+	}
+
+	if r.Record != "" {
+		if r.For != "" || r.KeepFiringFor != "" {
+			errs = append(errs, errors.New("for and keep_firing_for are only valid for alerts"))
+		}
+		if len(r.Annotations) > 0 {
+			errs = append(errs, errors.New("annotations are only valid for alerts"))
+		}
+	}
+
+	if r.For != "" && !isDuration(r.For) {
+		errs = append(errs, fmt.Errorf("invalid for duration %q", r.For))
+	}
+
+	if r.KeepFiringFor != "" && !isDuration(r.KeepFiringFor) {
+		errs = append(errs, fmt.Errorf("invalid keep_firing_for duration %q", r.KeepFiringFor))
+	}
+
+	// The names are sorted so the errors are reported in a stable order.
+	for _, name := range slices.Sorted(maps.Keys(r.Labels)) {
+		if !labelNameRegex.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid label name %q", name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(r.Annotations)) {
+		if !labelNameRegex.MatchString(name) {
+			errs = append(errs, fmt.Errorf("invalid annotation name %q", name))
+		}
+	}
+
+	return errs
+}
+
+// isDuration reports whether s is a valid Prometheus duration, e.g. `1h30m`.
+func isDuration(s string) bool {
+	return s != "" && durationRegex.MatchString(s)
+}
@@ -0,0 +1,187 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseAndValidate(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		name           string
+		content        string
+		expectedErrors []string
+	}{
+		{
+			name: "valid file",
+			content: `
+groups:
+  - name: example
+    interval: 1m
+    rules:
+      - record: job:http_requests:rate5m
+        expr: sum by (job) (rate(http_requests_total[5m]))
+      - alert: HighErrorRate
+        expr: job:http_errors:rate5m > 0.5
+        for: 10m
+        labels:
+          severity: page
+        annotations:
+          summary: High error rate
+`,
+		},
+		{
+			name:           "empty file",
+			content:        "",
+			expectedErrors: []string{"no rule groups defined"},
+		},
+		{
+			name: "invalid groups",
+			content: `
+groups:
+  - name: example
+    interval: 1minute
+    rules: []
+  - name: example
+    rules:
+      - alert: Test
+        record: test
+        expr: up == 0
+`,
+			expectedErrors: []string{
+				`group "example": invalid interval "1minute"`,
+				`group "example": no rules defined`,
+				`group "example": name is duplicated`,
+				`group "example": rule 0: only one of record or alert can be set`,
+			},
+		},
+		{
+			name: "invalid rules",
+			content: `
+groups:
+  - name: example
+    rules:
+      - record: invalid-name
+        expr: up
+        for: 5m
+      - alert: Test
+        expr: " "
+        for: ten
+        labels:
+          invalid-label: x
+      - expr: up
+`,
+			expectedErrors: []string{
+				`rule 0: invalid record name "invalid-name"`,
+				"rule 0: for and keep_firing_for are only valid for alerts",
+				"rule 1: expr is required",
+				`rule 1: invalid for duration "ten"`,
+				`rule 1: invalid label name "invalid-label"`,
+				"rule 2: one of record or alert is required",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := Parse([]byte(tc.content))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = file.Validate()
+
+			if len(tc.expectedErrors) == 0 {
+				if err != nil {
+					t.Fatalf("expected no validation errors, got %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected validation errors, got nil")
+			}
+
+			for _, expected := range tc.expectedErrors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Errorf("expected error %q in \n%v", expected, err)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateErrorsOrder(t *testing.T) {
+	t.Parallel()
+
+	file, err := Parse([]byte(`
+groups:
+  - name: example
+    rules:
+      - alert: Test
+        expr: up
+        labels:
+          d-label: x
+          b-label: x
+          c-label: x
+          a-label: x
+        annotations:
+          z-annotation: x
+          y-annotation: x
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"a-label", "b-label", "c-label", "d-label", "y-annotation", "z-annotation"}
+
+	// The map iteration order differs between runs.
+	for range 10 {
+		message := file.Validate().Error()
+
+		last := -1
+		for _, name := range expected {
+			i := strings.Index(message, name)
+			if i <= last {
+				t.Fatalf("expected the errors to be sorted by name %v, got \n%v", expected, message)
+			}
+			last = i
+		}
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	t.Parallel()
+
+	_, err := Parse([]byte("groups:\n  - name: example\n    unknown: true\n"))
+	if err == nil || !strings.Contains(err.Error(), "field unknown not found") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+
+	content := "groups:\n  - name: example\n    rules:\n      - alert: Down\n        expr: up == 0\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.Validate(); err != nil {
+		t.Fatalf("expected no validation errors, got %v", err)
+	}
+
+	if _, err := ParseFile(path + ".missing"); err == nil {
+		t.Fatal("expected missing file error, got nil")
+	}
+}
@@ -0,0 +1,130 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporters supported by Config.Exporter.
+const (
+	ExporterNone     = "none"
+	ExporterStdout   = "stdout"
+	ExporterOTLPHTTP = "otlp-http"
+)
+
+// DefaultServiceName is the `service.name` of the exported spans.
+const DefaultServiceName = "sentinel"
+
+// Propagator reads and writes the W3C `traceparent` and `tracestate`
+// headers.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// Config defines a Provider configuration option.
+type Config struct {
+	// Exporter is none (default), stdout or otlp-http.
+	Exporter string
+
+	// Endpoint is the OTLP HTTP collector url, e.g.
+	// `http://localhost:4318`. The OTEL_EXPORTER_OTLP_* env variables
+	// are used when empty.
+	Endpoint string
+
+	ServiceName string
+
+	// SampleRatio is the fraction (0, 1] of the new traces sampled. Values
+	// outside of this range, such as the zero value, sample every trace.
+	// Traces started by a sampled parent are always sampled.
+	SampleRatio float64
+
+	// Writer is the stdout exporter output, os.Stdout by default.
+	Writer io.Writer
+
+	// SpanExporter takes precedence over Exporter. The spans are exported
+	// synchronously, e.g. to a tracetest.InMemoryExporter in tests.
+	SpanExporter sdktrace.SpanExporter
+}
+
+// Provider is a trace.TracerProvider that must be shut down to export
+// the buffered spans before exiting.
+type Provider struct {
+	trace.TracerProvider
+
+	shutdown func(ctx context.Context) error
+}
+
+// NewProvider creates the tracer provider for the configured exporter. It
+// returns a no-op provider when the exporter is none.
+func NewProvider(config Config) (*Provider, error) {
+	if config.ServiceName == "" {
+		config.ServiceName = DefaultServiceName
+	}
+
+	if config.SampleRatio <= 0 || config.SampleRatio > 1 {
+		config.SampleRatio = 1
+	}
+
+	var option sdktrace.TracerProviderOption
+
+	switch {
+	case config.SpanExporter != nil:
+		option = sdktrace.WithSyncer(config.SpanExporter)
+	case config.Exporter == "" || config.Exporter == ExporterNone:
+		return &Provider{
+			TracerProvider: noop.NewTracerProvider(),
+			shutdown:       func(context.Context) error { return nil },
+		}, nil
+	case config.Exporter == ExporterStdout:
+		writer := config.Writer
+		if writer == nil {
+			writer = os.Stdout
+		}
+
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+		if err != nil {
+			return nil, err
+		}
+		option = sdktrace.WithBatcher(exporter)
+	case config.Exporter == ExporterOTLPHTTP:
+		var options []otlptracehttp.Option
+		if config.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpointURL(config.Endpoint))
+		}
+
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			return nil, err
+		}
+		option = sdktrace.WithBatcher(exporter)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", config.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		option,
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", config.ServiceName),
+		)),
+	)
+
+	return &Provider{
+		TracerProvider: provider,
+		shutdown:       provider.Shutdown,
+	}, nil
+}
+
+// Shutdown exports the buffered spans and stops the provider.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
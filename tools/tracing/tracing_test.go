@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNewProvider(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    Config
+		recording bool
+		hasError  bool
+	}{
+		{"default", Config{}, false, false},
+		{"none", Config{Exporter: ExporterNone}, false, false},
+		{"stdout", Config{Exporter: ExporterStdout, Writer: new(bytes.Buffer)}, true, false},
+		{"otlp http", Config{Exporter: ExporterOTLPHTTP, Endpoint: "http://127.0.0.1:4318"}, true, false},
+		{"span exporter", Config{SpanExporter: tracetest.NewInMemoryExporter()}, true, false},
+		{"unknown", Config{Exporter: "jaeger"}, false, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := NewProvider(tc.config)
+
+			hasError := err != nil
+			if hasError != tc.hasError {
+				t.Fatalf("expected hasError to be %v, got %v (%v)", tc.hasError, hasError, err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			_, span := provider.Tracer("test").Start(context.Background(), "test")
+			span.End()
+
+			if span.IsRecording() != tc.recording && span.SpanContext().IsValid() != tc.recording {
+				t.Fatalf("expected span recording to be %v", tc.recording)
+			}
+
+			// The otlp exporter has no collector to export to.
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			_ = provider.Shutdown(ctx)
+		})
+	}
+}
+
+func TestNewProviderStdout(t *testing.T) {
+	buf := new(bytes.Buffer)
+
+	provider, err := NewProvider(Config{Exporter: ExporterStdout, Writer: buf, ServiceName: "test-service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, span := provider.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	if err := provider.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, content := range []string{`"Name":"test-span"`, `"Value":"test-service"`} {
+		if !strings.Contains(buf.String(), content) {
+			t.Errorf("expected output to contain %q, got %q", content, buf.String())
+		}
+	}
+}